@@ -0,0 +1,61 @@
+// Package apikey generates and recognizes personal API keys. A key looks
+// like ghk_1a2b3c4d_<secret>: the part before the second underscore is its
+// prefix, which is stored and shown so users can tell their keys apart,
+// while only a hash of the whole key is stored.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Prefix starts every key, so keys are told apart from access tokens and
+// secret scanners can find leaked ones
+const Prefix = "ghk_"
+
+// prefixSize and secretSize are the random bytes in the visible prefix and
+// in the secret
+const (
+	prefixSize = 4
+	secretSize = 32
+)
+
+// Generate returns a new key and its visible prefix
+func Generate() (key, prefix string) {
+	prefix = Prefix + randomHex(prefixSize)
+	return prefix + "_" + randomHex(secretSize), prefix
+}
+
+// IsKey reports whether a bearer credential is an API key rather than an
+// access token
+func IsKey(credential string) bool {
+	return strings.HasPrefix(credential, Prefix)
+}
+
+// VisiblePrefix returns the prefix of a key, or "" if it is malformed
+func VisiblePrefix(key string) string {
+	if !IsKey(key) {
+		return ""
+	}
+	end := strings.Index(key[len(Prefix):], "_")
+	if end <= 0 {
+		return ""
+	}
+	return key[:len(Prefix)+end]
+}
+
+// Hash is what is stored for a key
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(size int) string {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,37 @@
+package apikey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test keys carry their visible prefix and are unique
+func TestGenerate(t *testing.T) {
+	key, prefix := Generate()
+	other, otherPrefix := Generate()
+
+	assert.True(t, IsKey(key))
+	assert.Len(t, prefix, len(Prefix)+8)
+	assert.Equal(t, prefix, VisiblePrefix(key))
+	assert.Len(t, key, len(prefix)+1+64)
+	assert.NotEqual(t, key, other)
+	assert.NotEqual(t, prefix, otherPrefix)
+}
+
+// ✅ Test access tokens and malformed keys are told apart from keys
+func TestVisiblePrefix(t *testing.T) {
+	assert.False(t, IsKey("eyJhbGciOiJIUzI1NiJ9.e30.sig"))
+	assert.Equal(t, "", VisiblePrefix("eyJhbGciOiJIUzI1NiJ9.e30.sig"))
+	assert.Equal(t, "", VisiblePrefix("ghk_nounderscore"))
+	assert.Equal(t, "", VisiblePrefix("ghk__secret"))
+	assert.Equal(t, "ghk_1a2b3c4d", VisiblePrefix("ghk_1a2b3c4d_secret"))
+}
+
+// ✅ Test only a stable hash of a key is stored
+func TestHash(t *testing.T) {
+	key, _ := Generate()
+	assert.Equal(t, Hash(key), Hash(key))
+	assert.NotEqual(t, key, Hash(key))
+	assert.Len(t, Hash(key), 64)
+}
@@ -0,0 +1,56 @@
+// Command import-jobs bulk-loads job postings from a CSV or JSON file, using
+// the same validation and upsert rules as POST /api/jobs/import.
+//
+//	go run ./cmd/import-jobs -file jobs.csv -dry-run
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/handlers"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the CSV or JSON file to import")
+	format := flag.String("format", "", "csv or json (defaults to the file extension)")
+	dryRun := flag.Bool("dry-run", false, "validate every row without writing to the database")
+	importedBy := flag.String("imported-by", "", "profile ID recorded as the creator of new jobs")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	// Initialize database connection
+	db.InitDB()
+	defer db.CloseDB()
+
+	report, err := handlers.RunJobImport(f, *format, *dryRun, *importedBy)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
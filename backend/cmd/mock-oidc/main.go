@@ -0,0 +1,44 @@
+// Command mock-oidc runs a local OpenID Connect provider for trying single
+// sign-on without a real identity provider. Every login is approved as the
+// configured identity.
+//
+//	go run ./cmd/mock-oidc -addr :9400 -client-id gatorhire
+//
+// and configure the API with OIDC_PROVIDERS=mock, OIDC_MOCK_ISSUER=http://localhost:9400
+// and OIDC_MOCK_CLIENT_ID=gatorhire.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gatorhire/backend/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", ":9400", "address to listen on")
+	issuer := flag.String("issuer", "", "issuer URL (defaults to http://localhost<addr>)")
+	clientID := flag.String("client-id", "gatorhire", "accepted client ID")
+	clientSecret := flag.String("client-secret", "", "accepted client secret")
+	subject := flag.String("subject", "mock-user", "subject of the logged in user")
+	email := flag.String("email", "albert@ufl.edu", "email of the logged in user")
+	name := flag.String("name", "Albert Gator", "name of the logged in user")
+	emailVerified := flag.Bool("email-verified", true, "whether the email is reported as verified")
+	flag.Parse()
+
+	if *issuer == "" {
+		host := *addr
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		*issuer = "http://" + host
+	}
+
+	provider := oidctest.New(*issuer, *clientID, *clientSecret)
+	provider.SetIdentity(oidctest.Identity{Subject: *subject, Email: *email, EmailVerified: *emailVerified, Name: *name})
+
+	log.Printf("Mock OpenID Connect provider running at %s (client %s, user %s)", *issuer, *clientID, *email)
+	log.Fatal(http.ListenAndServe(*addr, provider))
+}
@@ -0,0 +1,28 @@
+-- Adds a first-class work arrangement to jobs and a matching preference to profiles.
+-- Existing rows are classified from the free-text location/type columns, which is
+-- where "Remote" and "Hybrid" used to be typed.
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS work_arrangement TEXT NOT NULL DEFAULT 'onsite'
+    CHECK (work_arrangement IN ('remote', 'hybrid', 'onsite'));
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS remote_regions JSONB;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS remote_time_zones JSONB;
+CREATE INDEX IF NOT EXISTS idx_jobs_work_arrangement ON jobs (work_arrangement);
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS work_preference TEXT
+    CHECK (work_preference IN ('remote', 'hybrid', 'onsite'));
+
+-- Hybrid first: "Hybrid (2 days remote)" mentions both words
+UPDATE jobs SET work_arrangement = 'hybrid'
+WHERE location ILIKE '%hybrid%' OR type ILIKE '%hybrid%';
+
+UPDATE jobs SET work_arrangement = 'remote'
+WHERE work_arrangement = 'onsite'
+  AND (location ILIKE '%remote%' OR type ILIKE '%remote%'
+       OR location ILIKE '%work from home%' OR location ILIKE '%anywhere%');
+
+-- "Remote - US" / "Remote (US)" style locations carry the allowed region
+UPDATE jobs SET remote_regions = jsonb_build_array(
+        upper(trim(both ' ()-' from substring(location from '(?i)remote\s*[-(,]\s*([A-Za-z ]+)\)?$'))))
+WHERE work_arrangement = 'remote'
+  AND remote_regions IS NULL
+  AND location ~* 'remote\s*[-(,]\s*[A-Za-z ]+\)?$';
@@ -0,0 +1,44 @@
+-- Keeps an immutable snapshot of every job update and records which revision
+-- an applicant saw when they applied.
+
+CREATE TABLE IF NOT EXISTS job_revisions (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    revision INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    changed_by TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (job_id, revision)
+);
+
+CREATE OR REPLACE FUNCTION forbid_job_revision_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'job revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS job_revisions_immutable ON job_revisions;
+CREATE TRIGGER job_revisions_immutable
+    BEFORE UPDATE ON job_revisions
+    FOR EACH ROW EXECUTE FUNCTION forbid_job_revision_update();
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS job_revision_id TEXT REFERENCES job_revisions(id);
+
+-- Existing jobs start their history at revision 1 with their current contents.
+-- posted_date is stored without a zone and read as UTC, so it is written the
+-- way Go encodes it (RFC 3339).
+INSERT INTO job_revisions (id, job_id, revision, snapshot, changed_by, created_at)
+SELECT gen_random_uuid()::text, j.id, 1,
+       jsonb_build_object(
+           'id', j.id, 'title', j.title, 'company', j.company, 'location', j.location,
+           'type', j.type, 'salary', j.salary, 'description', j.description,
+           'requirements', j.requirements, 'responsibilities', j.responsibilities,
+           'benefits', j.benefits, 'postedDate', to_char(j.posted_date, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'), 'category', j.category,
+           'status', j.status, 'companyInfo', j.company_info, 'createdBy', j.created_by,
+           'workArrangement', j.work_arrangement, 'remoteRegions', j.remote_regions,
+           'remoteTimeZones', j.remote_time_zones
+       ),
+       j.created_by, NOW()
+FROM jobs j
+WHERE NOT EXISTS (SELECT 1 FROM job_revisions r WHERE r.job_id = j.id);
+
@@ -0,0 +1,2 @@
+-- Employer reference IDs let bulk imports update postings instead of duplicating them
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS external_id TEXT UNIQUE;
@@ -0,0 +1,32 @@
+-- Saved searches and the queue of job alert notifications they produce
+
+-- Create saved_searches table (depends on profiles)
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    keywords TEXT,
+    category TEXT,
+    type TEXT,
+    location TEXT,
+    min_salary INTEGER,
+    frequency TEXT NOT NULL DEFAULT 'daily' CHECK (frequency IN ('instant', 'daily', 'weekly')),
+    muted BOOLEAN NOT NULL DEFAULT FALSE,
+    unsubscribe_token TEXT NOT NULL UNIQUE,
+    last_matched_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create alert_notifications table (depends on saved_searches, profiles and jobs)
+CREATE TABLE IF NOT EXISTS alert_notifications (
+    id TEXT PRIMARY KEY,
+    saved_search_id TEXT NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES profiles(id),
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    deliver_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (saved_search_id, job_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_notifications_pending ON alert_notifications (deliver_at) WHERE sent_at IS NULL;
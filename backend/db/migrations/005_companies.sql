@@ -0,0 +1,57 @@
+-- Moves company data out of the per-job company_info blob into a shared
+-- companies table that recruiters can belong to.
+
+-- Create companies table (no dependencies)
+CREATE TABLE IF NOT EXISTS companies (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    logo_url TEXT,
+    website TEXT,
+    industry TEXT,
+    size TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create company_members table (depends on companies and profiles)
+CREATE TABLE IF NOT EXISTS company_members (
+    company_id TEXT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'recruiter' CHECK (role IN ('owner', 'recruiter')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, user_id)
+);
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_id TEXT REFERENCES companies(id);
+CREATE INDEX IF NOT EXISTS idx_jobs_company_id ON jobs (company_id);
+
+-- One company per distinct slug; the most recently posted job's details win
+INSERT INTO companies (id, name, slug, description, website, industry, size, created_at)
+SELECT DISTINCT ON (slug)
+       gen_random_uuid()::text, name, slug,
+       COALESCE(company_info->>'description', ''),
+       NULLIF(company_info->>'website', ''),
+       NULLIF(company_info->>'industry', ''),
+       NULLIF(company_info->>'size', ''),
+       NOW()
+FROM (
+    SELECT j.*, COALESCE(NULLIF(j.company_info->>'name', ''), j.company) AS name,
+           trim(both '-' from regexp_replace(lower(COALESCE(NULLIF(j.company_info->>'name', ''), j.company)), '[^a-z0-9]+', '-', 'g')) AS slug
+    FROM jobs j
+) named
+ORDER BY slug, posted_date DESC
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE jobs j SET company_id = c.id
+FROM companies c
+WHERE j.company_id IS NULL
+  AND c.slug = trim(both '-' from regexp_replace(lower(COALESCE(NULLIF(j.company_info->>'name', ''), j.company)), '[^a-z0-9]+', '-', 'g'));
+
+-- Recruiters who posted a company's jobs become its members
+INSERT INTO company_members (company_id, user_id, role)
+SELECT DISTINCT j.company_id, j.created_by, 'recruiter'
+FROM jobs j
+JOIN profiles p ON p.id = j.created_by
+WHERE j.company_id IS NOT NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,45 @@
+-- Daily funnel rollups for job analytics. Applications and saves made before
+-- this migration are backfilled; views were never recorded.
+
+-- Create job_daily_stats table (depends on jobs): the analytics rollup
+CREATE TABLE IF NOT EXISTS job_daily_stats (
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    day DATE NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    saves INTEGER NOT NULL DEFAULT 0,
+    applications INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (job_id, day)
+);
+
+-- Create job_view_viewers table (depends on jobs): one row per viewer per job per day, for de-duplication
+CREATE TABLE IF NOT EXISTS job_view_viewers (
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    day DATE NOT NULL,
+    viewer_key TEXT NOT NULL,
+    PRIMARY KEY (job_id, day, viewer_key)
+);
+
+-- Saves are only backfilled into days without stats, so re-running this does
+-- not reset the counts kept by the trigger below, which include saves that
+-- were undone since
+INSERT INTO job_daily_stats (job_id, day, saves)
+SELECT job_id, saved_date::date, COUNT(*) FROM saved_jobs GROUP BY job_id, saved_date::date
+ON CONFLICT (job_id, day) DO NOTHING;
+
+INSERT INTO job_daily_stats (job_id, day, applications)
+SELECT job_id, created_at::date, COUNT(*) FROM applications GROUP BY job_id, created_at::date
+ON CONFLICT (job_id, day) DO UPDATE SET applications = EXCLUDED.applications;
+
+-- Saves are counted as they are stored, whichever handler stores them
+CREATE OR REPLACE FUNCTION count_job_save() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO job_daily_stats (job_id, day, saves) VALUES (NEW.job_id, NEW.saved_date::date, 1)
+    ON CONFLICT (job_id, day) DO UPDATE SET saves = job_daily_stats.saves + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS saved_jobs_count_save ON saved_jobs;
+CREATE TRIGGER saved_jobs_count_save
+    AFTER INSERT ON saved_jobs
+    FOR EACH ROW EXECUTE FUNCTION count_job_save();
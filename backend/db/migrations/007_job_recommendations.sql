@@ -0,0 +1,9 @@
+-- Jobs a user dismissed are left out of their recommendations
+
+-- Create job_dismissals table (depends on profiles and jobs): jobs a user hid from their recommendations
+CREATE TABLE IF NOT EXISTS job_dismissals (
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, job_id)
+);
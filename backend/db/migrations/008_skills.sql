@@ -0,0 +1,98 @@
+-- Canonical skills taxonomy. Existing jobs get their skills extracted by the
+-- server at startup (BackfillJobSkills); profiles are normalized on their next update.
+
+-- Create skills table (no dependencies): the canonical skills taxonomy
+CREATE TABLE IF NOT EXISTS skills (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    category TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create skill_aliases table (depends on skills): other spellings of a skill, stored normalized
+CREATE TABLE IF NOT EXISTS skill_aliases (
+    alias TEXT PRIMARY KEY,
+    skill_id TEXT NOT NULL REFERENCES skills(id) ON DELETE CASCADE
+);
+
+-- Create job_skills table (depends on jobs and skills): skills extracted from a job's requirements
+CREATE TABLE IF NOT EXISTS job_skills (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    skill_id TEXT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    PRIMARY KEY (job_id, skill_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_skills_skill_id ON job_skills (skill_id);
+
+-- Create profile_skills table (depends on profiles and skills): a user's skills, normalized
+CREATE TABLE IF NOT EXISTS profile_skills (
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    skill_id TEXT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, skill_id)
+);
+
+INSERT INTO skills (id, name, category) VALUES
+    ('go', 'Go', 'Programming Languages'),
+    ('python', 'Python', 'Programming Languages'),
+    ('javascript', 'JavaScript', 'Programming Languages'),
+    ('typescript', 'TypeScript', 'Programming Languages'),
+    ('java', 'Java', 'Programming Languages'),
+    ('cpp', 'C++', 'Programming Languages'),
+    ('csharp', 'C#', 'Programming Languages'),
+    ('sql', 'SQL', 'Databases'),
+    ('postgresql', 'PostgreSQL', 'Databases'),
+    ('mysql', 'MySQL', 'Databases'),
+    ('mongodb', 'MongoDB', 'Databases'),
+    ('react', 'React', 'Frameworks'),
+    ('nodejs', 'Node.js', 'Frameworks'),
+    ('angular', 'Angular', 'Frameworks'),
+    ('django', 'Django', 'Frameworks'),
+    ('html', 'HTML', 'Web'),
+    ('css', 'CSS', 'Web'),
+    ('rest-apis', 'REST APIs', 'Web'),
+    ('docker', 'Docker', 'Cloud & DevOps'),
+    ('kubernetes', 'Kubernetes', 'Cloud & DevOps'),
+    ('aws', 'AWS', 'Cloud & DevOps'),
+    ('git', 'Git', 'Cloud & DevOps'),
+    ('machine-learning', 'Machine Learning', 'Data'),
+    ('data-analysis', 'Data Analysis', 'Data'),
+    ('excel', 'Excel', 'Data'),
+    ('tableau', 'Tableau', 'Data'),
+    ('figma', 'Figma', 'Design'),
+    ('ux-design', 'UX Design', 'Design'),
+    ('project-management', 'Project Management', 'Business'),
+    ('marketing', 'Marketing', 'Business'),
+    ('accounting', 'Accounting', 'Business'),
+    ('customer-service', 'Customer Service', 'Soft Skills'),
+    ('communication', 'Communication', 'Soft Skills'),
+    ('leadership', 'Leadership', 'Soft Skills'),
+    ('patient-care', 'Patient Care', 'Healthcare'),
+    ('cpr', 'CPR', 'Healthcare'),
+    ('tutoring', 'Tutoring', 'Education')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO skill_aliases (alias, skill_id) VALUES
+    ('golang', 'go'), ('go lang', 'go'),
+    ('python3', 'python'),
+    ('js', 'javascript'), ('ecmascript', 'javascript'),
+    ('ts', 'typescript'),
+    ('c plus plus', 'cpp'), ('cplusplus', 'cpp'),
+    ('c sharp', 'csharp'), ('.net', 'csharp'),
+    ('postgres', 'postgresql'), ('psql', 'postgresql'),
+    ('mongo', 'mongodb'),
+    ('reactjs', 'react'), ('react.js', 'react'),
+    ('node', 'nodejs'), ('nodejs', 'nodejs'),
+    ('angularjs', 'angular'),
+    ('html5', 'html'), ('css3', 'css'),
+    ('rest', 'rest-apis'), ('restful', 'rest-apis'), ('rest api', 'rest-apis'), ('restful api', 'rest-apis'), ('restful apis', 'rest-apis'),
+    ('k8s', 'kubernetes'),
+    ('amazon web services', 'aws'),
+    ('github', 'git'),
+    ('ml', 'machine-learning'),
+    ('data analytics', 'data-analysis'),
+    ('microsoft excel', 'excel'), ('spreadsheets', 'excel'),
+    ('ux', 'ux-design'), ('ui/ux', 'ux-design'), ('user experience', 'ux-design'),
+    ('customer support', 'customer-service'),
+    ('communication skills', 'communication'),
+    ('tutor', 'tutoring')
+ON CONFLICT (alias) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Postings submitted by employers wait in a moderation queue until approved.
+-- Existing jobs were posted by admins and stay live.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS moderation_status TEXT NOT NULL DEFAULT 'approved'
+    CHECK (moderation_status IN ('pending', 'approved', 'rejected'));
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS moderation_flags JSONB;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS moderation_reason TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS moderated_by TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_moderation_pending ON jobs (posted_date) WHERE moderation_status = 'pending';
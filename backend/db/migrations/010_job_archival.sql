@@ -0,0 +1,5 @@
+-- Deleting a job archives it; archived jobs are purged after a retention period
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS archived_by TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_archived_at ON jobs (archived_at) WHERE archived_at IS NOT NULL;
@@ -0,0 +1,24 @@
+-- Screening settings per job, and per-company job templates for cloning postings
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS screening JSONB;
+
+-- Create job_templates table (depends on companies): reusable postings with {{variables}}
+CREATE TABLE IF NOT EXISTS job_templates (
+    id TEXT PRIMARY KEY,
+    company_id TEXT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    title TEXT NOT NULL,
+    location TEXT NOT NULL DEFAULT '',
+    type TEXT NOT NULL DEFAULT '',
+    salary TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    requirements JSONB,
+    responsibilities JSONB,
+    benefits JSONB,
+    work_arrangement TEXT,
+    screening JSONB,
+    created_by TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (company_id, name)
+);
@@ -0,0 +1,3 @@
+-- Custom application forms: a JSON Schema of extra questions per job, and the answers per application
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS application_form JSONB;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS answers JSONB;
@@ -0,0 +1,12 @@
+-- Partly filled applications candidates saved to finish later
+
+-- Create application_drafts table (depends on profiles and jobs): one draft per candidate and job
+CREATE TABLE IF NOT EXISTS application_drafts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, job_id)
+);
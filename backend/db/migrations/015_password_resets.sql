@@ -0,0 +1,14 @@
+-- Password reset links
+
+-- Create password_resets table (depends on profiles): reset requests, including ones for unknown emails, which only count towards the rate limit
+CREATE TABLE IF NOT EXISTS password_resets (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    user_id TEXT REFERENCES profiles(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_email ON password_resets (email, created_at);
@@ -0,0 +1,10 @@
+-- Verified students: accounts that proved they own an address on a trusted
+-- university domain, and jobs only they can see and apply to.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS verified_student BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS student_email TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profiles_student_email ON profiles (LOWER(student_email));
+
+ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS purpose TEXT NOT NULL DEFAULT 'account'
+    CHECK (purpose IN ('account', 'student'));
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS students_only BOOLEAN NOT NULL DEFAULT FALSE;
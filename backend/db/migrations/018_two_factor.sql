@@ -0,0 +1,32 @@
+-- TOTP two-factor authentication. The secret is set when enrollment starts
+-- and only used once totp_enabled; totp_last_step stops a code being used
+-- twice, totp_failures/totp_failed_at lock out guessing.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS totp_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS totp_failed_at TIMESTAMP;
+
+-- Sessions that passed two-factor authentication
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS mfa BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create two_factor_challenges table (depends on profiles): logins whose password was accepted and that wait for a TOTP code
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create two_factor_recovery_codes table (depends on profiles): single-use codes for when the authenticator app is lost
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_two_factor_recovery_codes_user_id ON two_factor_recovery_codes (user_id);
@@ -0,0 +1,30 @@
+-- OpenID Connect single sign-on
+-- Create user_identities table (depends on profiles): identity provider accounts linked to a profile for single sign-on
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    email TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_user_provider ON user_identities (user_id, provider);
+
+-- Create oidc_logins table (depends on profiles): single sign-on attempts, from leaving for the provider until the site exchanges its one-time code
+CREATE TABLE IF NOT EXISTS oidc_logins (
+    id TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    state_hash TEXT NOT NULL UNIQUE,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    link_user_id TEXT REFERENCES profiles(id) ON DELETE CASCADE,
+    redirect_path TEXT NOT NULL DEFAULT '/',
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    user_id TEXT REFERENCES profiles(id) ON DELETE CASCADE,
+    login_code_hash TEXT UNIQUE,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
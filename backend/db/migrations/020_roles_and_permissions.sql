@@ -0,0 +1,19 @@
+-- Roles beyond user/admin. profiles.role may also be 'career_services';
+-- company members can be hiring managers and interviewers, and hiring teams
+-- grant roles for a single job. See the rbac package for what each role may do.
+ALTER TABLE company_members DROP CONSTRAINT IF EXISTS company_members_role_check;
+ALTER TABLE company_members ADD CONSTRAINT company_members_role_check
+    CHECK (role IN ('owner', 'recruiter', 'hiring_manager', 'interviewer'));
+
+CREATE INDEX IF NOT EXISTS idx_company_members_user_id ON company_members (user_id);
+
+-- Create job_members table (depends on jobs and profiles): a job's hiring team
+CREATE TABLE IF NOT EXISTS job_members (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'interviewer' CHECK (role IN ('recruiter', 'hiring_manager', 'interviewer')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (job_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_members_user_id ON job_members (user_id);
@@ -0,0 +1,8 @@
+-- Brute-force protection for logins
+-- Create login_throttles table (no dependencies): consecutive failed logins per email address ("account:...") and per client address ("ip:...")
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP
+);
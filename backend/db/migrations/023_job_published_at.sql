@@ -0,0 +1,28 @@
+-- When each job last became visible to the public. Alerts match on it rather
+-- than posted_date, which approved submissions, restored jobs and imports
+-- keep from before they were published.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS published_at TIMESTAMP;
+
+-- Jobs that are already public were published when they were posted
+UPDATE jobs SET published_at = posted_date
+WHERE published_at IS NULL AND status = 'active' AND moderation_status = 'approved' AND archived_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_published_at ON jobs (published_at);
+
+CREATE OR REPLACE FUNCTION set_job_published_at() RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = 'active' AND NEW.moderation_status = 'approved' AND NEW.archived_at IS NULL THEN
+        IF TG_OP = 'INSERT' THEN
+            NEW.published_at := NOW();
+        ELSIF NOT (OLD.status = 'active' AND OLD.moderation_status = 'approved' AND OLD.archived_at IS NULL) THEN
+            NEW.published_at := NOW();
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_published_at ON jobs;
+CREATE TRIGGER jobs_published_at
+    BEFORE INSERT OR UPDATE ON jobs
+    FOR EACH ROW EXECUTE FUNCTION set_job_published_at();
@@ -9,10 +9,168 @@ CREATE TABLE profiles (
     bio TEXT,
     skills JSONB,
     role TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    work_preference TEXT CHECK (work_preference IN ('remote', 'hybrid', 'onsite')),
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    verified_student BOOLEAN NOT NULL DEFAULT FALSE,
+    student_email TEXT,
+    totp_secret TEXT,
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    totp_failures INTEGER NOT NULL DEFAULT 0,
+    totp_failed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_profiles_student_email ON profiles (LOWER(student_email));
+
+-- Create refresh_tokens table (depends on profiles): one row per issued refresh token, grouped into families per login
+CREATE TABLE refresh_tokens (
+    id TEXT PRIMARY KEY,
+    family_id TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    mfa BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+-- Create two_factor_challenges table (depends on profiles): logins whose password was accepted and that wait for a TOTP code
+CREATE TABLE two_factor_challenges (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create two_factor_recovery_codes table (depends on profiles): single-use codes for when the authenticator app is lost
+CREATE TABLE two_factor_recovery_codes (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_two_factor_recovery_codes_user_id ON two_factor_recovery_codes (user_id);
+
+-- Create user_identities table (depends on profiles): identity provider accounts linked to a profile for single sign-on
+CREATE TABLE user_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    email TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE UNIQUE INDEX idx_user_identities_user_provider ON user_identities (user_id, provider);
+
+-- Create oidc_logins table (depends on profiles): single sign-on attempts, from leaving for the provider until the site exchanges its one-time code
+CREATE TABLE oidc_logins (
+    id TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    state_hash TEXT NOT NULL UNIQUE,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    link_user_id TEXT REFERENCES profiles(id) ON DELETE CASCADE,
+    redirect_path TEXT NOT NULL DEFAULT '/',
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    user_id TEXT REFERENCES profiles(id) ON DELETE CASCADE,
+    login_code_hash TEXT UNIQUE,
+    completed_at TIMESTAMP,
     created_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
--- Create jobs table (no dependencies)
+-- Create api_keys table (depends on profiles): personal API keys; only a hash of each key is stored, with its visible prefix
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
+
+-- Create login_throttles table (no dependencies): consecutive failed logins per email address ("account:...") and per client address ("ip:...")
+CREATE TABLE login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP
+);
+
+-- Create revoked_tokens table (no dependencies): access tokens revoked before they expire, by jti
+CREATE TABLE revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Create password_resets table (depends on profiles): reset requests, including ones for unknown emails, which only count towards the rate limit
+CREATE TABLE password_resets (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    user_id TEXT REFERENCES profiles(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_resets_email ON password_resets (email, created_at);
+
+-- Create email_verifications table (depends on profiles): links sent to confirm an account's email address or a student address
+CREATE TABLE email_verifications (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    purpose TEXT NOT NULL DEFAULT 'account' CHECK (purpose IN ('account', 'student')),
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_verifications_user_id ON email_verifications (user_id, created_at);
+
+-- Create companies table (no dependencies)
+CREATE TABLE companies (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    logo_url TEXT,
+    website TEXT,
+    industry TEXT,
+    size TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create company_members table (depends on companies and profiles)
+CREATE TABLE company_members (
+    company_id TEXT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'recruiter' CHECK (role IN ('owner', 'recruiter', 'hiring_manager', 'interviewer')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, user_id)
+);
+
+CREATE INDEX idx_company_members_user_id ON company_members (user_id);
+
+-- Create jobs table (depends on companies)
 CREATE TABLE jobs (
     id TEXT PRIMARY KEY,
     title TEXT NOT NULL,
@@ -28,10 +186,109 @@ CREATE TABLE jobs (
     category TEXT NOT NULL,
     status TEXT NOT NULL,
     company_info JSONB,
-    created_by TEXT
+    created_by TEXT,
+    work_arrangement TEXT NOT NULL DEFAULT 'onsite' CHECK (work_arrangement IN ('remote', 'hybrid', 'onsite')),
+    remote_regions JSONB,
+    remote_time_zones JSONB,
+    external_id TEXT UNIQUE,
+    company_id TEXT REFERENCES companies(id),
+    moderation_status TEXT NOT NULL DEFAULT 'approved' CHECK (moderation_status IN ('pending', 'approved', 'rejected')),
+    moderation_flags JSONB,
+    moderation_reason TEXT,
+    moderated_by TEXT,
+    moderated_at TIMESTAMP,
+    archived_at TIMESTAMP,
+    archived_by TEXT,
+    screening JSONB,
+    application_form JSONB,
+    students_only BOOLEAN NOT NULL DEFAULT FALSE,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX idx_jobs_archived_at ON jobs (archived_at) WHERE archived_at IS NOT NULL;
+
+CREATE INDEX idx_jobs_moderation_pending ON jobs (posted_date) WHERE moderation_status = 'pending';
+
+CREATE INDEX idx_jobs_company_id ON jobs (company_id);
+
+CREATE INDEX idx_jobs_work_arrangement ON jobs (work_arrangement);
+
+CREATE INDEX idx_jobs_published_at ON jobs (published_at);
+
+-- published_at records when a job last became visible to the public, which
+-- job alerts match on
+CREATE FUNCTION set_job_published_at() RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = 'active' AND NEW.moderation_status = 'approved' AND NEW.archived_at IS NULL THEN
+        IF TG_OP = 'INSERT' THEN
+            NEW.published_at := NOW();
+        ELSIF NOT (OLD.status = 'active' AND OLD.moderation_status = 'approved' AND OLD.archived_at IS NULL) THEN
+            NEW.published_at := NOW();
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER jobs_published_at
+    BEFORE INSERT OR UPDATE ON jobs
+    FOR EACH ROW EXECUTE FUNCTION set_job_published_at();
+
+-- Create job_members table (depends on jobs and profiles): a job's hiring team
+CREATE TABLE job_members (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'interviewer' CHECK (role IN ('recruiter', 'hiring_manager', 'interviewer')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (job_id, user_id)
+);
+
+CREATE INDEX idx_job_members_user_id ON job_members (user_id);
+
+-- Create job_templates table (depends on companies): reusable postings with {{variables}}
+CREATE TABLE job_templates (
+    id TEXT PRIMARY KEY,
+    company_id TEXT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    title TEXT NOT NULL,
+    location TEXT NOT NULL DEFAULT '',
+    type TEXT NOT NULL DEFAULT '',
+    salary TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    requirements JSONB,
+    responsibilities JSONB,
+    benefits JSONB,
+    work_arrangement TEXT,
+    screening JSONB,
+    created_by TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (company_id, name)
 );
 
--- Create applications table (depends on jobs and profiles)
+-- Create job_revisions table (depends on jobs); rows are never updated
+CREATE TABLE job_revisions (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    revision INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    changed_by TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (job_id, revision)
+);
+
+CREATE FUNCTION forbid_job_revision_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'job revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER job_revisions_immutable
+    BEFORE UPDATE ON job_revisions
+    FOR EACH ROW EXECUTE FUNCTION forbid_job_revision_update();
+
+-- Create applications table (depends on jobs, job_revisions and profiles)
 CREATE TABLE applications (
     id TEXT PRIMARY KEY,
     job_id TEXT NOT NULL REFERENCES jobs(id),
@@ -45,7 +302,9 @@ CREATE TABLE applications (
     portfolio TEXT,
     heard_from TEXT,
     created_at TIMESTAMP NOT NULL,
-    status TEXT NOT NULL
+    status TEXT NOT NULL,
+    job_revision_id TEXT REFERENCES job_revisions(id),
+    answers JSONB
 );
 
 -- Create saved_jobs table (depends on profiles and jobs)
@@ -56,4 +315,181 @@ CREATE TABLE saved_jobs (
     saved_date TIMESTAMP NOT NULL DEFAULT NOW(),
     CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES profiles(id),
     CONSTRAINT fk_job FOREIGN KEY (job_id) REFERENCES jobs(id)
-);
\ No newline at end of file
+);
+
+-- Create saved_searches table (depends on profiles)
+CREATE TABLE saved_searches (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    keywords TEXT,
+    category TEXT,
+    type TEXT,
+    location TEXT,
+    min_salary INTEGER,
+    frequency TEXT NOT NULL DEFAULT 'daily' CHECK (frequency IN ('instant', 'daily', 'weekly')),
+    muted BOOLEAN NOT NULL DEFAULT FALSE,
+    unsubscribe_token TEXT NOT NULL UNIQUE,
+    last_matched_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create alert_notifications table (depends on saved_searches, profiles and jobs)
+CREATE TABLE alert_notifications (
+    id TEXT PRIMARY KEY,
+    saved_search_id TEXT NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES profiles(id),
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    deliver_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (saved_search_id, job_id)
+);
+
+CREATE INDEX idx_alert_notifications_pending ON alert_notifications (deliver_at) WHERE sent_at IS NULL;
+
+-- Create job_daily_stats table (depends on jobs): the analytics rollup
+CREATE TABLE job_daily_stats (
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    day DATE NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    saves INTEGER NOT NULL DEFAULT 0,
+    applications INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (job_id, day)
+);
+
+-- Saves are counted as they are stored, whichever handler stores them
+CREATE FUNCTION count_job_save() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO job_daily_stats (job_id, day, saves) VALUES (NEW.job_id, NEW.saved_date::date, 1)
+    ON CONFLICT (job_id, day) DO UPDATE SET saves = job_daily_stats.saves + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER saved_jobs_count_save
+    AFTER INSERT ON saved_jobs
+    FOR EACH ROW EXECUTE FUNCTION count_job_save();
+
+-- Create job_view_viewers table (depends on jobs): one row per viewer per job per day, for de-duplication
+CREATE TABLE job_view_viewers (
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    day DATE NOT NULL,
+    viewer_key TEXT NOT NULL,
+    PRIMARY KEY (job_id, day, viewer_key)
+);
+
+-- Create job_dismissals table (depends on profiles and jobs): jobs a user hid from their recommendations
+CREATE TABLE job_dismissals (
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    job_id TEXT NOT NULL REFERENCES jobs(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, job_id)
+);
+
+-- Create application_drafts table (depends on profiles and jobs): one draft per candidate and job
+CREATE TABLE application_drafts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, job_id)
+);
+
+-- Create skills table (no dependencies): the canonical skills taxonomy
+CREATE TABLE skills (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    category TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create skill_aliases table (depends on skills): other spellings of a skill, stored normalized
+CREATE TABLE skill_aliases (
+    alias TEXT PRIMARY KEY,
+    skill_id TEXT NOT NULL REFERENCES skills(id) ON DELETE CASCADE
+);
+
+-- Create job_skills table (depends on jobs and skills): skills extracted from a job's requirements
+CREATE TABLE job_skills (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    skill_id TEXT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    PRIMARY KEY (job_id, skill_id)
+);
+
+CREATE INDEX idx_job_skills_skill_id ON job_skills (skill_id);
+
+-- Create profile_skills table (depends on profiles and skills): a user's skills, normalized
+CREATE TABLE profile_skills (
+    user_id TEXT NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    skill_id TEXT NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, skill_id)
+);
+
+INSERT INTO skills (id, name, category) VALUES
+    ('go', 'Go', 'Programming Languages'),
+    ('python', 'Python', 'Programming Languages'),
+    ('javascript', 'JavaScript', 'Programming Languages'),
+    ('typescript', 'TypeScript', 'Programming Languages'),
+    ('java', 'Java', 'Programming Languages'),
+    ('cpp', 'C++', 'Programming Languages'),
+    ('csharp', 'C#', 'Programming Languages'),
+    ('sql', 'SQL', 'Databases'),
+    ('postgresql', 'PostgreSQL', 'Databases'),
+    ('mysql', 'MySQL', 'Databases'),
+    ('mongodb', 'MongoDB', 'Databases'),
+    ('react', 'React', 'Frameworks'),
+    ('nodejs', 'Node.js', 'Frameworks'),
+    ('angular', 'Angular', 'Frameworks'),
+    ('django', 'Django', 'Frameworks'),
+    ('html', 'HTML', 'Web'),
+    ('css', 'CSS', 'Web'),
+    ('rest-apis', 'REST APIs', 'Web'),
+    ('docker', 'Docker', 'Cloud & DevOps'),
+    ('kubernetes', 'Kubernetes', 'Cloud & DevOps'),
+    ('aws', 'AWS', 'Cloud & DevOps'),
+    ('git', 'Git', 'Cloud & DevOps'),
+    ('machine-learning', 'Machine Learning', 'Data'),
+    ('data-analysis', 'Data Analysis', 'Data'),
+    ('excel', 'Excel', 'Data'),
+    ('tableau', 'Tableau', 'Data'),
+    ('figma', 'Figma', 'Design'),
+    ('ux-design', 'UX Design', 'Design'),
+    ('project-management', 'Project Management', 'Business'),
+    ('marketing', 'Marketing', 'Business'),
+    ('accounting', 'Accounting', 'Business'),
+    ('customer-service', 'Customer Service', 'Soft Skills'),
+    ('communication', 'Communication', 'Soft Skills'),
+    ('leadership', 'Leadership', 'Soft Skills'),
+    ('patient-care', 'Patient Care', 'Healthcare'),
+    ('cpr', 'CPR', 'Healthcare'),
+    ('tutoring', 'Tutoring', 'Education')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO skill_aliases (alias, skill_id) VALUES
+    ('golang', 'go'), ('go lang', 'go'),
+    ('python3', 'python'),
+    ('js', 'javascript'), ('ecmascript', 'javascript'),
+    ('ts', 'typescript'),
+    ('c plus plus', 'cpp'), ('cplusplus', 'cpp'),
+    ('c sharp', 'csharp'), ('.net', 'csharp'),
+    ('postgres', 'postgresql'), ('psql', 'postgresql'),
+    ('mongo', 'mongodb'),
+    ('reactjs', 'react'), ('react.js', 'react'),
+    ('node', 'nodejs'), ('nodejs', 'nodejs'),
+    ('angularjs', 'angular'),
+    ('html5', 'html'), ('css3', 'css'),
+    ('rest', 'rest-apis'), ('restful', 'rest-apis'), ('rest api', 'rest-apis'), ('restful api', 'rest-apis'), ('restful apis', 'rest-apis'),
+    ('k8s', 'kubernetes'),
+    ('amazon web services', 'aws'),
+    ('github', 'git'),
+    ('ml', 'machine-learning'),
+    ('data analytics', 'data-analysis'),
+    ('microsoft excel', 'excel'), ('spreadsheets', 'excel'),
+    ('ux', 'ux-design'), ('ui/ux', 'ux-design'), ('user experience', 'ux-design'),
+    ('customer support', 'customer-service'),
+    ('communication skills', 'communication'),
+    ('tutor', 'tutoring')
+ON CONFLICT (alias) DO NOTHING;
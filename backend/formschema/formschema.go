@@ -0,0 +1,393 @@
+// Package formschema validates answers to custom application forms. A form is
+// described with a subset of JSON Schema: an object whose properties are
+// strings, numbers, integers, booleans or arrays of strings, with the usual
+// validation keywords. File fields are strings with "format": "file" holding
+// the uploaded file's URL; "x-accept" limits their extensions.
+//
+// Example:
+//
+//	{
+//	  "type": "object",
+//	  "required": ["transcript", "startDate"],
+//	  "properties": {
+//	    "transcript": {"type": "string", "format": "file", "title": "Unofficial transcript", "x-accept": [".pdf"]},
+//	    "startDate": {"type": "string", "format": "date", "title": "Earliest start date"},
+//	    "hoursPerWeek": {"type": "integer", "minimum": 10, "maximum": 40}
+//	  }
+//	}
+package formschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is one question of a form
+type Field struct {
+	Name        string
+	Title       string
+	Description string
+	Type        string // "string", "number", "integer", "boolean" or "array"
+	Format      string // for strings: "date", "email", "uri" or "file"
+	Required    bool
+
+	Enum      []string
+	MinLength *int
+	MaxLength *int
+	Pattern   *regexp.Regexp
+	Minimum   *float64
+	Maximum   *float64
+	MinItems  *int
+	MaxItems  *int
+	// ItemEnum lists the allowed values of an array field's items
+	ItemEnum []string
+	// Accept lists the file extensions a file field allows (".pdf")
+	Accept []string
+}
+
+// Label is the field's title, falling back to its name
+func (f Field) Label() string {
+	if f.Title != "" {
+		return f.Title
+	}
+	return f.Name
+}
+
+// Schema is a parsed form, with its fields in the order they were declared
+type Schema struct {
+	Title  string
+	Fields []Field
+}
+
+// FieldError is a validation problem with one answer
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Answer is one answer formatted for display
+type Answer struct {
+	Field string `json:"field"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// property is the JSON Schema subset accepted for a field
+type property struct {
+	Type        string   `json:"type"`
+	Format      string   `json:"format"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum"`
+	MinLength   *int     `json:"minLength"`
+	MaxLength   *int     `json:"maxLength"`
+	Pattern     string   `json:"pattern"`
+	Minimum     *float64 `json:"minimum"`
+	Maximum     *float64 `json:"maximum"`
+	MinItems    *int     `json:"minItems"`
+	MaxItems    *int     `json:"maxItems"`
+	Items       *struct {
+		Type string   `json:"type"`
+		Enum []string `json:"enum"`
+	} `json:"items"`
+	Accept []string `json:"x-accept"`
+}
+
+var supportedFormats = map[string]bool{"": true, "date": true, "email": true, "uri": true, "file": true}
+
+// Parse reads and checks a form schema
+func Parse(raw []byte) (*Schema, error) {
+	var document struct {
+		Type       string          `json:"type"`
+		Title      string          `json:"title"`
+		Required   []string        `json:"required"`
+		Properties json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("form is not valid JSON: %v", err)
+	}
+	if document.Type != "object" {
+		return nil, fmt.Errorf(`form "type" must be "object"`)
+	}
+
+	names, err := objectKeys(document.Properties)
+	if err != nil {
+		return nil, fmt.Errorf(`form "properties" must be an object`)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("form has no fields")
+	}
+	var properties map[string]property
+	if err := json.Unmarshal(document.Properties, &properties); err != nil {
+		return nil, fmt.Errorf("invalid form field: %v", err)
+	}
+
+	required := map[string]bool{}
+	for _, name := range document.Required {
+		if _, ok := properties[name]; !ok {
+			return nil, fmt.Errorf("required field %q is not defined", name)
+		}
+		required[name] = true
+	}
+
+	schema := &Schema{Title: document.Title}
+	for _, name := range names {
+		field, err := parseField(name, properties[name])
+		if err != nil {
+			return nil, err
+		}
+		field.Required = required[name]
+		schema.Fields = append(schema.Fields, field)
+	}
+	return schema, nil
+}
+
+// objectKeys returns the keys of a JSON object in document order
+func objectKeys(raw json.RawMessage) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("not an object")
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, token.(string))
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func parseField(name string, p property) (Field, error) {
+	field := Field{
+		Name: name, Title: p.Title, Description: p.Description, Type: p.Type, Format: p.Format,
+		Enum: p.Enum, MinLength: p.MinLength, MaxLength: p.MaxLength, Minimum: p.Minimum, Maximum: p.Maximum,
+		MinItems: p.MinItems, MaxItems: p.MaxItems, Accept: p.Accept,
+	}
+
+	switch p.Type {
+	case "string":
+		if !supportedFormats[p.Format] {
+			return field, fmt.Errorf("field %q has unsupported format %q", name, p.Format)
+		}
+	case "number", "integer", "boolean":
+	case "array":
+		if p.Items == nil || p.Items.Type != "string" {
+			return field, fmt.Errorf("field %q must be an array of strings", name)
+		}
+		field.ItemEnum = p.Items.Enum
+	default:
+		return field, fmt.Errorf("field %q has unsupported type %q", name, p.Type)
+	}
+
+	if p.Pattern != "" {
+		pattern, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return field, fmt.Errorf("field %q has an invalid pattern: %v", name, err)
+		}
+		field.Pattern = pattern
+	}
+	for i, extension := range field.Accept {
+		field.Accept[i] = "." + strings.TrimPrefix(strings.ToLower(extension), ".")
+	}
+	return field, nil
+}
+
+// Validate checks answers against the form. It returns the answers to store,
+// keeping only the form's fields, and every problem found.
+func (s *Schema) Validate(answers map[string]interface{}) (map[string]interface{}, []FieldError) {
+	cleaned := map[string]interface{}{}
+	var problems []FieldError
+
+	for _, field := range s.Fields {
+		value, ok := answers[field.Name]
+		if !ok || isEmpty(value) {
+			if field.Required {
+				problems = append(problems, FieldError{field.Name, field.Label() + " is required"})
+			}
+			continue
+		}
+		if message := field.check(value); message != "" {
+			problems = append(problems, FieldError{field.Name, field.Label() + " " + message})
+			continue
+		}
+		cleaned[field.Name] = value
+	}
+	return cleaned, problems
+}
+
+// isEmpty reports whether an answer counts as missing: null, empty text or
+// no choices at all
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// check validates one non-empty answer and describes the problem, if any
+func (f Field) check(value interface{}) string {
+	switch f.Type {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return "must be text"
+		}
+		return f.checkString(text)
+	case "number", "integer":
+		number, ok := value.(float64)
+		if !ok {
+			return "must be a number"
+		}
+		if f.Type == "integer" && number != float64(int64(number)) {
+			return "must be a whole number"
+		}
+		if f.Minimum != nil && number < *f.Minimum {
+			return fmt.Sprintf("must be at least %s", formatNumber(*f.Minimum))
+		}
+		if f.Maximum != nil && number > *f.Maximum {
+			return fmt.Sprintf("must be at most %s", formatNumber(*f.Maximum))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return "must be true or false"
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return "must be a list"
+		}
+		if f.MinItems != nil && len(items) < *f.MinItems {
+			return fmt.Sprintf("needs at least %d choices", *f.MinItems)
+		}
+		if f.MaxItems != nil && len(items) > *f.MaxItems {
+			return fmt.Sprintf("allows at most %d choices", *f.MaxItems)
+		}
+		for _, item := range items {
+			text, ok := item.(string)
+			if !ok {
+				return "must be a list of text"
+			}
+			if len(f.ItemEnum) > 0 && !contains(f.ItemEnum, text) {
+				return fmt.Sprintf("has an invalid choice %q", text)
+			}
+		}
+	}
+	return ""
+}
+
+func (f Field) checkString(text string) string {
+	length := len([]rune(text))
+	if f.MinLength != nil && length < *f.MinLength {
+		return fmt.Sprintf("must be at least %d characters", *f.MinLength)
+	}
+	if f.MaxLength != nil && length > *f.MaxLength {
+		return fmt.Sprintf("must be at most %d characters", *f.MaxLength)
+	}
+	if len(f.Enum) > 0 && !contains(f.Enum, text) {
+		return "must be one of " + strings.Join(f.Enum, ", ")
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(text) {
+		return "is not in the expected format"
+	}
+
+	switch f.Format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case "email":
+		if _, err := mail.ParseAddress(text); err != nil {
+			return "must be an email address"
+		}
+	case "uri", "file":
+		link, err := url.Parse(text)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return "must be an http(s) link"
+		}
+		if f.Format == "file" && len(f.Accept) > 0 && !contains(f.Accept, strings.ToLower(path.Ext(link.Path))) {
+			return "must be a " + strings.Join(f.Accept, " or ") + " file"
+		}
+	}
+	return ""
+}
+
+// Render formats stored answers for display, in form order. Answers to
+// fields that were since removed from the form are listed last.
+func (s *Schema) Render(answers map[string]interface{}) []Answer {
+	rendered := []Answer{}
+	seen := map[string]bool{}
+	for _, field := range s.Fields {
+		seen[field.Name] = true
+		rendered = append(rendered, Answer{Field: field.Name, Label: field.Label(), Value: FormatValue(answers[field.Name])})
+	}
+
+	var extra []string
+	for name := range answers {
+		if !seen[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		rendered = append(rendered, Answer{Field: name, Label: name, Value: FormatValue(answers[name])})
+	}
+	return rendered
+}
+
+// FormatValue turns a stored answer into display text
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "No"
+	case float64:
+		return formatNumber(v)
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			parts = append(parts, FormatValue(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
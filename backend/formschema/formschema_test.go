@@ -0,0 +1,120 @@
+package formschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testForm = `{
+	"type": "object",
+	"required": ["transcript", "startDate", "hoursPerWeek"],
+	"properties": {
+		"transcript": {"type": "string", "format": "file", "title": "Unofficial transcript", "x-accept": ["PDF"]},
+		"startDate": {"type": "string", "format": "date", "title": "Earliest start date"},
+		"hoursPerWeek": {"type": "integer", "minimum": 10, "maximum": 40},
+		"authorized": {"type": "boolean", "title": "Authorized to work in the US"},
+		"shifts": {"type": "array", "items": {"type": "string", "enum": ["morning", "evening"]}, "maxItems": 2},
+		"ufid": {"type": "string", "pattern": "^[0-9]{8}$"}
+	}
+}`
+
+func answers(t *testing.T, raw string) map[string]interface{} {
+	var values map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(raw), &values))
+	return values
+}
+
+// ✅ Test parsing keeps field order and rejects unsupported forms
+func TestParse(t *testing.T) {
+	schema, err := Parse([]byte(testForm))
+	assert.Nil(t, err)
+
+	var names []string
+	for _, field := range schema.Fields {
+		names = append(names, field.Name)
+	}
+	assert.Equal(t, []string{"transcript", "startDate", "hoursPerWeek", "authorized", "shifts", "ufid"}, names)
+	assert.True(t, schema.Fields[0].Required)
+	assert.False(t, schema.Fields[3].Required)
+	assert.Equal(t, []string{".pdf"}, schema.Fields[0].Accept)
+
+	for _, raw := range []string{
+		`{"type": "array"}`,
+		`{"type": "object", "properties": {}}`,
+		`{"type": "object", "properties": {"a": {"type": "object"}}}`,
+		`{"type": "object", "properties": {"a": {"type": "string", "format": "color"}}}`,
+		`{"type": "object", "properties": {"a": {"type": "string", "pattern": "("}}}`,
+		`{"type": "object", "required": ["b"], "properties": {"a": {"type": "string"}}}`,
+	} {
+		_, err := Parse([]byte(raw))
+		assert.NotNil(t, err, raw)
+	}
+}
+
+// ✅ Test validating answers reports each problem and drops unknown fields
+func TestValidate(t *testing.T) {
+	schema, _ := Parse([]byte(testForm))
+
+	cleaned, problems := schema.Validate(answers(t, `{
+		"transcript": "https://files.example.com/transcript.pdf",
+		"startDate": "2026-01-12",
+		"hoursPerWeek": 20,
+		"shifts": ["morning"],
+		"unknown": "dropped"
+	}`))
+	assert.Empty(t, problems)
+	assert.Len(t, cleaned, 4)
+	assert.NotContains(t, cleaned, "unknown")
+
+	_, problems = schema.Validate(answers(t, `{
+		"transcript": "https://files.example.com/transcript.docx",
+		"hoursPerWeek": 12.5,
+		"authorized": "yes",
+		"shifts": ["night"],
+		"ufid": "1234"
+	}`))
+	fields := map[string]string{}
+	for _, problem := range problems {
+		fields[problem.Field] = problem.Message
+	}
+	assert.Equal(t, map[string]string{
+		"transcript":   "Unofficial transcript must be a .pdf file",
+		"startDate":    "Earliest start date is required",
+		"hoursPerWeek": "hoursPerWeek must be a whole number",
+		"authorized":   "Authorized to work in the US must be true or false",
+		"shifts":       `shifts has an invalid choice "night"`,
+		"ufid":         "ufid is not in the expected format",
+	}, fields)
+}
+
+// ✅ Test an empty list does not answer a required question
+func TestValidateRequiredArray(t *testing.T) {
+	schema, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["shifts"],
+		"properties": {"shifts": {"type": "array", "items": {"type": "string", "enum": ["morning", "evening"]}}}
+	}`))
+	assert.Nil(t, err)
+
+	_, problems := schema.Validate(answers(t, `{"shifts": []}`))
+	assert.Equal(t, []FieldError{{"shifts", "shifts is required"}}, problems)
+
+	cleaned, problems := schema.Validate(answers(t, `{"shifts": ["evening"]}`))
+	assert.Empty(t, problems)
+	assert.Contains(t, cleaned, "shifts")
+}
+
+// ✅ Test rendering answers in form order, keeping answers to removed fields
+func TestRender(t *testing.T) {
+	schema, _ := Parse([]byte(testForm))
+	rendered := schema.Render(answers(t, `{"hoursPerWeek": 20, "authorized": true, "shifts": ["morning", "evening"], "old": "kept"}`))
+
+	assert.Len(t, rendered, 7)
+	assert.Equal(t, Answer{Field: "transcript", Label: "Unofficial transcript", Value: ""}, rendered[0])
+	assert.Equal(t, "20", rendered[2].Value)
+	assert.Equal(t, "Yes", rendered[3].Value)
+	assert.Equal(t, "morning, evening", rendered[4].Value)
+	assert.Equal(t, Answer{Field: "old", Label: "old", Value: "kept"}, rendered[6])
+}
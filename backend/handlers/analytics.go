@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gatorhire/backend/utils"
+	"github.com/gorilla/mux"
+)
+
+// Funnel events recorded for job analytics. Saves are counted by a trigger
+// on saved_jobs instead.
+const (
+	jobEventView        = "views"
+	jobEventApplication = "applications"
+)
+
+// analyticsWindow is the default date range of the analytics endpoints
+const analyticsWindow = 30 * 24 * time.Hour
+
+// jobEvent is one funnel event waiting to be written to the rollups
+type jobEvent struct {
+	jobID     string
+	kind      string
+	viewerKey string // only set for views
+	at        time.Time
+}
+
+// jobEvents buffers funnel events so that request handlers never wait on
+// analytics writes. Events are dropped, not blocked on, if the writer falls behind.
+var jobEvents = make(chan jobEvent, 1024)
+
+// trackJobEvent queues a funnel event for the analytics writer
+func trackJobEvent(jobID, kind, viewerKey string) {
+	select {
+	case jobEvents <- jobEvent{jobID: jobID, kind: kind, viewerKey: viewerKey, at: time.Now()}:
+	default:
+		log.Printf("⚠️ Analytics queue full, dropping %s event for job %s", kind, jobID)
+	}
+}
+
+// trackJobView queues a view of a job's detail page. GetJobByID calls it so
+// that server-rendered views are counted without the client's help.
+func trackJobView(r *http.Request, jobID string) {
+	trackJobEvent(jobID, jobEventView, viewerKey(r))
+}
+
+// viewerKey identifies a viewer for de-duplication: the logged-in user, else
+// the client's session ID, else a hash of its address and user agent. The
+// address leaves out the port, which changes with every connection.
+func viewerKey(r *http.Request) string {
+	if userID, _, err := utils.GetUserFromToken(r); err == nil {
+		return "user:" + userID
+	}
+	if session := r.Header.Get("X-Session-ID"); session != "" {
+		return "session:" + session
+	}
+	sum := sha256.Sum256([]byte(remoteHost(r) + "|" + r.UserAgent()))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
+// remoteHost returns the address of the connection a request came in on,
+// without the port
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// StartAnalyticsWriter drains queued funnel events into the daily rollups in
+// the background and prunes view de-duplication rows that are no longer needed
+func StartAnalyticsWriter() {
+	go func() {
+		prune := time.NewTicker(time.Hour)
+		defer prune.Stop()
+		for {
+			select {
+			case event := <-jobEvents:
+				if err := writeJobEvent(event); err != nil {
+					log.Printf("❌ Error recording %s event for job %s: %v", event.kind, event.jobID, err)
+				}
+			case <-prune.C:
+				if _, err := db.DB.Exec("DELETE FROM job_view_viewers WHERE day < CURRENT_DATE - 1"); err != nil {
+					log.Printf("❌ Error pruning job view viewers: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// writeJobEvent adds one event to the job's rollup for that day. A view only
+// counts the first time a viewer opens the job on a given day.
+func writeJobEvent(event jobEvent) error {
+	day := event.at.Format("2006-01-02")
+
+	if event.kind == jobEventView {
+		result, err := db.DB.Exec(`
+			INSERT INTO job_view_viewers (job_id, day, viewer_key) VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, event.jobID, day, event.viewerKey)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil
+		}
+	}
+
+	// kind is one of the jobEvent* constants, never user input
+	_, err := db.DB.Exec(fmt.Sprintf(`
+		INSERT INTO job_daily_stats (job_id, day, %[1]s) VALUES ($1, $2, 1)
+		ON CONFLICT (job_id, day) DO UPDATE SET %[1]s = job_daily_stats.%[1]s + 1
+	`, event.kind), event.jobID, day)
+	return err
+}
+
+// RecordJobView counts a view of a job's detail page. The frontend calls it
+// when the page is opened, sending an X-Session-ID header for anonymous users.
+func RecordJobView(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["id"]
+	var exists bool
+	if err := db.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1)", jobID).Scan(&exists); err != nil || !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	trackJobView(r, jobID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// analyticsRange reads the from/to query parameters (YYYY-MM-DD), defaulting
+// to the last 30 days
+func analyticsRange(r *http.Request) (string, string, error) {
+	to := time.Now()
+	from := to.Add(-analyticsWindow)
+	var err error
+
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			return "", "", err
+		}
+	}
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			return "", "", err
+		}
+	}
+	if from.After(to) {
+		return "", "", fmt.Errorf("from must not be after to")
+	}
+	return from.Format("2006-01-02"), to.Format("2006-01-02"), nil
+}
+
+// queryFunnel builds funnel analytics from job_daily_stats rows matching the
+// given condition on the stats table (aliased s)
+func queryFunnel(condition string, id, from, to string) (models.FunnelAnalytics, error) {
+	funnel := models.FunnelAnalytics{From: from, To: to, Daily: []models.JobDailyStats{}}
+
+	rows, err := db.DB.Query(`
+		SELECT to_char(s.day, 'YYYY-MM-DD'), SUM(s.views), SUM(s.saves), SUM(s.applications)
+		FROM job_daily_stats s
+		WHERE `+condition+` AND s.day BETWEEN $2 AND $3
+		GROUP BY s.day
+		ORDER BY s.day
+	`, id, from, to)
+	if err != nil {
+		return funnel, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day models.JobDailyStats
+		if err := rows.Scan(&day.Date, &day.Views, &day.Saves, &day.Applications); err != nil {
+			return funnel, err
+		}
+		funnel.Views += day.Views
+		funnel.Saves += day.Saves
+		funnel.Applications += day.Applications
+		funnel.Daily = append(funnel.Daily, day)
+	}
+
+	funnel.ViewToSave = conversionRate(funnel.Saves, funnel.Views)
+	funnel.ViewToApply = conversionRate(funnel.Applications, funnel.Views)
+	funnel.SaveToApply = conversionRate(funnel.Applications, funnel.Saves)
+	return funnel, rows.Err()
+}
+
+// conversionRate returns part/whole rounded to four decimals, or 0 when whole is 0
+func conversionRate(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(int(float64(part)/float64(whole)*10000+0.5)) / 10000
+}
+
+// GetJobAnalytics returns the daily view → save → application funnel of a job
+// (analytics:read)
+func GetJobAnalytics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["id"]
+	scope, err := rbac.JobScope(jobID)
+	if err != nil && err != sql.ErrNoRows {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !authorize(w, r, rbac.AnalyticsRead, scope) {
+		return
+	}
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	from, to, err := analyticsRange(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid date range"})
+		return
+	}
+
+	funnel, err := queryFunnel("s.job_id = $1", jobID, from, to)
+	if err != nil {
+		log.Printf("❌ Error querying job analytics: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	funnel.JobID = jobID
+
+	json.NewEncoder(w).Encode(funnel)
+}
+
+// GetCompanyAnalytics returns the combined daily funnel of all of a company's
+// jobs (analytics:read)
+func GetCompanyAnalytics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	companyID := mux.Vars(r)["id"]
+	if !authorize(w, r, rbac.AnalyticsRead, rbac.Scope{CompanyID: companyID}) {
+		return
+	}
+
+	from, to, err := analyticsRange(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid date range"})
+		return
+	}
+
+	funnel, err := queryFunnel(
+		"s.job_id IN (SELECT id FROM jobs WHERE company_id = $1)", companyID, from, to,
+	)
+	if err != nil {
+		log.Printf("❌ Error querying company analytics: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	funnel.CompanyID = companyID
+
+	json.NewEncoder(w).Encode(funnel)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test conversion rates round to four decimals and tolerate empty funnels
+func TestConversionRate(t *testing.T) {
+	assert.Equal(t, 0.0, conversionRate(3, 0))
+	assert.Equal(t, 0.25, conversionRate(1, 4))
+	assert.Equal(t, 0.3333, conversionRate(1, 3))
+}
+
+// ✅ Test viewers are identified by session before falling back to a fingerprint
+func TestViewerKey(t *testing.T) {
+	req := httptest.NewRequest("POST", "/jobs/1/views", nil)
+	req.Header.Set("X-Session-ID", "abc")
+	assert.Equal(t, "session:abc", viewerKey(req))
+
+	anonymous := httptest.NewRequest("POST", "/jobs/1/views", nil)
+	assert.Contains(t, viewerKey(anonymous), "anon:")
+	assert.Equal(t, viewerKey(anonymous), viewerKey(anonymous))
+
+	// A new connection from the same browser is the same viewer
+	reconnected := httptest.NewRequest("POST", "/jobs/1/views", nil)
+	reconnected.RemoteAddr = "192.0.2.1:41000"
+	assert.Equal(t, viewerKey(anonymous), viewerKey(reconnected))
+}
+
+// ✅ Test analytics date range parsing
+func TestAnalyticsRange(t *testing.T) {
+	req := httptest.NewRequest("GET", "/analytics/jobs/1?from=2025-01-01&to=2025-01-31", nil)
+	from, to, err := analyticsRange(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "2025-01-01", from)
+	assert.Equal(t, "2025-01-31", to)
+
+	_, _, err = analyticsRange(httptest.NewRequest("GET", "/analytics/jobs/1?from=2025-02-01&to=2025-01-01", nil))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/apikey"
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// API keys expire after defaultAPIKeyDays unless the user picks another
+// lifetime, up to maxAPIKeyDays. maxAPIKeys bounds the live keys per user.
+// Every key of an account is revoked along with its sessions when its
+// password is reset, its role changes or an SSO login takes it over.
+const (
+	defaultAPIKeyDays = 90
+	maxAPIKeyDays     = 365
+	maxAPIKeys        = 20
+)
+
+// apiKeyColumns are selected for scanAPIKey
+const apiKeyColumns = "id, name, prefix, scopes, expires_at, last_used_at, created_at"
+
+// scanAPIKey reads an api_keys row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &expiresAt, &lastUsedAt, &key.CreatedAt)
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return key, err
+}
+
+// validateAPIKeyScopes checks requested scopes are permissions the user
+// holds somewhere, and returns them de-duplicated. Keys are still limited to
+// what the user may do when they are used.
+func validateAPIKeyScopes(scopes []string, role string, grants []rbac.Grant) ([]string, string) {
+	if len(scopes) == 0 {
+		return nil, "At least one scope is required"
+	}
+	seen := map[string]bool{}
+	valid := []string{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		known := false
+		for _, permission := range rbac.Permissions {
+			if string(permission) == scope {
+				known = true
+			}
+		}
+		if !known {
+			return nil, "Unknown scope: " + scope
+		}
+		if !rbac.HeldAnywhere(role, grants, rbac.Permission(scope)) {
+			return nil, "You do not have the " + scope + " permission"
+		}
+		valid = append(valid, scope)
+	}
+	return valid, ""
+}
+
+// GetAPIKeys lists the user's API keys that have not been revoked
+func GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	rows, err := db.DB.Query(
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		log.Printf("❌ Error querying API keys: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			log.Printf("❌ Error scanning API key: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing API key data"})
+			return
+		}
+		keys = append(keys, key)
+	}
+
+	json.NewEncoder(w).Encode(keys)
+}
+
+// CreateAPIKey creates an API key for scripts and integrations. The key is
+// only ever shown in this response.
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, role, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expiresInDays"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || len(request.Name) > 100 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A name of up to 100 characters is required"})
+		return
+	}
+	if request.ExpiresInDays == 0 {
+		request.ExpiresInDays = defaultAPIKeyDays
+	}
+	if request.ExpiresInDays < 1 || request.ExpiresInDays > maxAPIKeyDays {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "API keys expire after 1 to 365 days"})
+		return
+	}
+
+	grants, err := rbac.LoadGrants(userID)
+	if err != nil {
+		log.Printf("❌ Error loading grants: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	scopes, problem := validateAPIKeyScopes(request.Scopes, role, grants)
+	if problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	var live int
+	err = db.DB.QueryRow(`
+		SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`, userID).Scan(&live)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if live >= maxAPIKeys {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Revoke an API key before creating another"})
+		return
+	}
+
+	key, prefix := apikey.Generate()
+	created, err := scanAPIKey(db.DB.QueryRow(`
+		INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING `+apiKeyColumns,
+		uuid.New().String(), userID, request.Name, prefix, apikey.Hash(key), pq.Array(scopes),
+		time.Now().Add(time.Duration(request.ExpiresInDays)*24*time.Hour),
+	))
+	if err != nil {
+		log.Printf("❌ Error creating API key: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create API key"})
+		return
+	}
+
+	log.Printf("✅ User %s created API key %s", userID, prefix)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.CreatedAPIKey{APIKey: created, Key: key})
+}
+
+// RevokeAPIKey stops one of the user's API keys working
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	result, err := db.DB.Exec(
+		"UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		mux.Vars(r)["id"], userID,
+	)
+	if err != nil {
+		log.Printf("❌ Error revoking API key: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to revoke API key"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "API key not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
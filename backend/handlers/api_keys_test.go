@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gatorhire/backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test API keys can only be given scopes the user holds
+func TestValidateAPIKeyScopes(t *testing.T) {
+	grants := []rbac.Grant{{Role: rbac.RoleInterviewer, CompanyID: "acme"}}
+
+	scopes, problem := validateAPIKeyScopes([]string{"applications:read", " applications:read", "jobs:read"}, "user", grants)
+	assert.Empty(t, problem)
+	assert.Equal(t, []string{"applications:read", "jobs:read"}, scopes)
+
+	_, problem = validateAPIKeyScopes([]string{"applications:export"}, "user", grants)
+	assert.NotEmpty(t, problem)
+	_, problem = validateAPIKeyScopes([]string{"jobs:everything"}, "admin", nil)
+	assert.NotEmpty(t, problem)
+	_, problem = validateAPIKeyScopes(nil, "admin", nil)
+	assert.NotEmpty(t, problem)
+
+	scopes, problem = validateAPIKeyScopes([]string{"jobs:import", "applications:export"}, "career_services", nil)
+	assert.Empty(t, problem)
+	assert.Len(t, scopes, 2)
+}
+
+// ✅ Test API keys are limited to their scopes and sessions are not
+func TestClaimsScopeAllows(t *testing.T) {
+	session := &utils.Claims{UserID: "user-1", Role: "admin"}
+	assert.True(t, session.ScopeAllows(rbac.UsersManage))
+
+	key := &utils.Claims{UserID: "user-1", Role: "admin", APIKeyID: "key-1", Scopes: []rbac.Permission{rbac.JobsImport}}
+	assert.True(t, key.ScopeAllows(rbac.JobsImport))
+	assert.False(t, key.ScopeAllows(rbac.UsersManage))
+}
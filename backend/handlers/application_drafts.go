@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxDraftSize caps the size of a saved application draft
+const maxDraftSize = 1 << 20
+
+// openDraftCondition selects drafts whose job still takes applications. A
+// draft expires as soon as its job is closed or deleted; drafts for a job
+// that is back in moderation are kept until the job is decided.
+const openDraftCondition = "j.status = 'active' AND j.archived_at IS NULL"
+
+// draftColumns is the column list scanApplicationDraft expects, in order.
+// Queries select from application_drafts d joined to jobs j.
+const draftColumns = `d.id, d.job_id, COALESCE((SELECT c.name FROM companies c WHERE c.id = j.company_id), j.company),
+	j.title, d.data, d.created_at, d.updated_at`
+
+// scanApplicationDraft reads a row selected with draftColumns
+func scanApplicationDraft(row rowScanner) (models.ApplicationDraft, error) {
+	var draft models.ApplicationDraft
+	var data []byte
+	err := row.Scan(&draft.ID, &draft.JobID, &draft.Company, &draft.JobTitle, &data, &draft.CreatedAt, &draft.UpdatedAt)
+	if err != nil {
+		return draft, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &draft.Application); err != nil {
+			return draft, err
+		}
+	}
+	draft.Application.JobID = draft.JobID
+	return draft, nil
+}
+
+// fetchApplicationDrafts lists a user's unexpired drafts, most recently
+// edited first
+func fetchApplicationDrafts(userID string) ([]models.ApplicationDraft, error) {
+	rows, err := db.DB.Query(`
+		SELECT `+draftColumns+`
+		FROM application_drafts d
+		JOIN jobs j ON j.id = d.job_id
+		WHERE d.user_id = $1 AND `+openDraftCondition+`
+		ORDER BY d.updated_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []models.ApplicationDraft{}
+	for rows.Next() {
+		draft, err := scanApplicationDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts, rows.Err()
+}
+
+// fetchApplicationDraft loads a user's unexpired draft for a job. It returns
+// sql.ErrNoRows when there is none.
+func fetchApplicationDraft(userID, jobID string) (models.ApplicationDraft, error) {
+	return scanApplicationDraft(db.DB.QueryRow(`
+		SELECT `+draftColumns+`
+		FROM application_drafts d
+		JOIN jobs j ON j.id = d.job_id
+		WHERE d.user_id = $1 AND d.job_id = $2 AND `+openDraftCondition,
+		userID, jobID))
+}
+
+// GetApplicationDrafts lists the current user's application drafts
+func GetApplicationDrafts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	drafts, err := fetchApplicationDrafts(userID)
+	if err != nil {
+		log.Printf("❌ Error fetching application drafts: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(drafts)
+}
+
+// GetApplicationDraft returns the current user's draft for a job, to resume it
+func GetApplicationDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	draft, err := fetchApplicationDraft(userID, mux.Vars(r)["id"])
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No draft for this job"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error fetching application draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(draft)
+}
+
+// SaveApplicationDraft creates or replaces the current user's draft for a
+// job. The body is a partly filled application; nothing is validated until
+// the draft is submitted.
+func SaveApplicationDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	// Drafts are only for jobs the user could apply to
+	jobID := mux.Vars(r)["id"]
+	studentAccess, err := userHasStudentAccess(userID)
+	var open bool
+	if err == nil {
+		err = db.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1 AND "+visibleJobCondition(studentAccess)+")", jobID).Scan(&open)
+	}
+	if err != nil {
+		log.Printf("❌ Error checking job for draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !open {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	var app models.Application
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDraftSize)).Decode(&app); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	// Only what the candidate typed is kept; the rest is set on submission
+	app.ID, app.UserID, app.Status, app.JobRevisionID = "", "", "", ""
+	app.JobID = jobID
+	app.CreatedAt = time.Time{}
+	app.RenderedAnswers = nil
+	data, _ := json.Marshal(app)
+
+	draft := models.ApplicationDraft{JobID: jobID, Application: app}
+	err = db.DB.QueryRow(`
+		INSERT INTO application_drafts (id, user_id, job_id, data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (user_id, job_id) DO UPDATE SET data = EXCLUDED.data, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, uuid.New().String(), userID, jobID, string(data)).Scan(&draft.ID, &draft.CreatedAt, &draft.UpdatedAt)
+	if err != nil {
+		log.Printf("❌ Error saving application draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save draft"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(draft)
+}
+
+// DeleteApplicationDraft discards the current user's draft for a job
+func DeleteApplicationDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	result, err := db.DB.Exec("DELETE FROM application_drafts WHERE user_id = $1 AND job_id = $2", userID, mux.Vars(r)["id"])
+	if err != nil {
+		log.Printf("❌ Error deleting application draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No draft for this job"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// SubmitApplicationDraft turns the current user's draft into an application.
+// It goes through the same checks as CreateApplication; the draft is removed
+// once the application is stored.
+func SubmitApplicationDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	draft, err := fetchApplicationDraft(userID, mux.Vars(r)["id"])
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No draft for this job"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error fetching application draft: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	app := draft.Application
+	app.JobID = draft.JobID
+	if app.FullName == "" {
+		err = db.DB.QueryRow("SELECT full_name FROM profiles WHERE id = $1", userID).Scan(&app.FullName)
+		if err != nil {
+			log.Printf("❌ Error fetching profile for draft submission: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "User not found"})
+			return
+		}
+	}
+	submitApplication(w, app, userID)
+}
+
+// GetCandidateDashboard returns the current user's saved jobs and
+// application drafts in one response
+func GetCandidateDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	dashboard := models.CandidateDashboard{SavedJobs: []models.SavedJob{}}
+	rows, err := db.DB.Query(`
+		SELECT `+jobColumns+`, s.saved_id, s.saved_date
+		FROM jobs
+		JOIN (SELECT id AS saved_id, job_id, saved_date FROM saved_jobs WHERE user_id = $1) s ON s.job_id = jobs.id
+		WHERE archived_at IS NULL
+		ORDER BY s.saved_date DESC
+	`, userID)
+	if err != nil {
+		log.Printf("❌ Error fetching saved jobs for dashboard: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		saved := models.SavedJob{UserID: userID}
+		job, err := scanJob(extraColumns{rows, []interface{}{&saved.ID, &saved.SavedDate}})
+		if err != nil {
+			log.Printf("❌ Error scanning saved job: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing job data"})
+			return
+		}
+		saved.JobID = job.ID
+		saved.Job = &job
+		dashboard.SavedJobs = append(dashboard.SavedJobs, saved)
+	}
+
+	dashboard.ApplicationDrafts, err = fetchApplicationDrafts(userID)
+	if err != nil {
+		log.Printf("❌ Error fetching application drafts: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(dashboard)
+}
+
+// expireApplicationDrafts deletes the drafts whose job has closed. Reads
+// already hide them; this only reclaims the rows.
+func expireApplicationDrafts() (int64, error) {
+	result, err := db.DB.Exec(`
+		DELETE FROM application_drafts d
+		USING jobs j
+		WHERE j.id = d.job_id AND NOT (` + openDraftCondition + `)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// StartApplicationDraftExpiry removes drafts of closed jobs in the background every interval
+func StartApplicationDraftExpiry(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := expireApplicationDrafts()
+			if err != nil {
+				log.Printf("❌ Error expiring application drafts: %v", err)
+			} else if expired > 0 {
+				log.Printf("🗑️ Expired %d application drafts of closed jobs", expired)
+			}
+		}
+	}()
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/formschema"
+	"github.com/gatorhire/backend/models"
+)
+
+// applicationColumns is the column list scanApplication expects, in order
+const applicationColumns = `a.id, a.job_id, a.user_id, a.full_name, a.email,
+	a.phone, a.cover_letter, a.resume_url, a.linkedin,
+	a.portfolio, a.heard_from, a.created_at, a.status,
+	COALESCE(a.job_revision_id, ''), a.answers`
+
+// scanApplication reads an applications row selected with applicationColumns
+func scanApplication(row rowScanner) (models.Application, error) {
+	var app models.Application
+	var answers []byte
+	err := row.Scan(
+		&app.ID, &app.JobID, &app.UserID, &app.FullName, &app.Email,
+		&app.Phone, &app.CoverLetter, &app.ResumeURL, &app.LinkedIn,
+		&app.Portfolio, &app.HeardFrom, &app.CreatedAt, &app.Status,
+		&app.JobRevisionID, &answers,
+	)
+	if err != nil {
+		return app, err
+	}
+	if len(answers) > 0 && string(answers) != "null" {
+		if err := json.Unmarshal(answers, &app.Answers); err != nil {
+			return app, err
+		}
+	}
+	return app, nil
+}
+
+// jobApplicationForm parses a job's application form. A job without a form
+// gets an empty one, which accepts no answers.
+func jobApplicationForm(job models.Job) (*formschema.Schema, error) {
+	if len(job.ApplicationForm) == 0 || string(job.ApplicationForm) == "null" {
+		return &formschema.Schema{}, nil
+	}
+	return formschema.Parse(job.ApplicationForm)
+}
+
+// checkApplicationAnswers validates an applicant's answers against the job's
+// form and returns the answers to store
+func checkApplicationAnswers(job models.Job, answers map[string]interface{}) (map[string]interface{}, []formschema.FieldError, error) {
+	form, err := jobApplicationForm(job)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleaned, problems := form.Validate(answers)
+	if len(cleaned) == 0 {
+		cleaned = nil
+	}
+	return cleaned, problems, nil
+}
+
+// fetchJobApplications loads a job's applications, newest first, with their
+// answers rendered against the job's current form. An unknown job simply has
+// no applications.
+func fetchJobApplications(jobID string) (*formschema.Schema, []models.Application, error) {
+	form := &formschema.Schema{}
+	job, err := fetchJob(jobID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	} else if err == nil {
+		if parsed, err := jobApplicationForm(job); err == nil {
+			form = parsed
+		} else {
+			// Show the raw answers rather than failing the whole listing
+			log.Printf("⚠️ Invalid application form on job %s: %v", jobID, err)
+		}
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT `+applicationColumns+`
+		FROM applications a
+		WHERE a.job_id = $1
+		ORDER BY a.created_at DESC
+	`, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applications := []models.Application{}
+	for rows.Next() {
+		app, err := scanApplication(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(form.Fields) > 0 || len(app.Answers) > 0 {
+			app.RenderedAnswers = form.Render(app.Answers)
+		}
+		applications = append(applications, app)
+	}
+	return form, applications, rows.Err()
+}
+
+// ExportApplications downloads a job's applications as CSV, with one column
+// per application form question. Its route requires applications:export for
+// the job.
+func ExportApplications(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Query().Get("jobId")
+	if jobID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job ID is required"})
+		return
+	}
+
+	form, applications, err := fetchJobApplications(jobID)
+	if err != nil {
+		log.Printf("❌ Error loading applications to export: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	filename := strings.Map(func(r rune) rune {
+		if r == '-' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+			return r
+		}
+		return -1
+	}, jobID)
+	w.Header().Set("Content-Disposition", `attachment; filename="applications-`+filename+`.csv"`)
+
+	writer := csv.NewWriter(w)
+	header := []string{
+		"Application ID", "Full name", "Email", "Phone", "Status", "Applied at",
+		"Resume", "LinkedIn", "Portfolio", "Heard from", "Cover letter",
+	}
+	for _, field := range form.Fields {
+		header = append(header, csvCell(field.Label()))
+	}
+	writer.Write(header)
+
+	for _, app := range applications {
+		record := []string{
+			app.ID, app.FullName, app.Email, app.Phone, app.Status, app.CreatedAt.Format(time.RFC3339),
+			app.ResumeURL, app.LinkedIn, app.Portfolio, app.HeardFrom, app.CoverLetter,
+		}
+		for _, field := range form.Fields {
+			record = append(record, formschema.FormatValue(app.Answers[field.Name]))
+		}
+		for i := range record {
+			record[i] = csvCell(record[i])
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("❌ Error writing applications export: %v", err)
+	}
+}
+
+// csvCell keeps applicant-supplied text from being read as a spreadsheet
+// formula when the export is opened
+func csvCell(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
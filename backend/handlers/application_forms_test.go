@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test answers are checked against the job's form, and ignored without one
+func TestCheckApplicationAnswers(t *testing.T) {
+	job := models.Job{ApplicationForm: json.RawMessage(`{
+		"type": "object",
+		"required": ["gpa"],
+		"properties": {"gpa": {"type": "number", "minimum": 0, "maximum": 4}}
+	}`)}
+
+	answers, problems, err := checkApplicationAnswers(job, map[string]interface{}{"gpa": 3.5, "extra": "x"})
+	assert.Nil(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, map[string]interface{}{"gpa": 3.5}, answers)
+
+	_, problems, _ = checkApplicationAnswers(job, map[string]interface{}{"gpa": 4.5})
+	assert.Len(t, problems, 1)
+	assert.Equal(t, "gpa", problems[0].Field)
+
+	answers, problems, err = checkApplicationAnswers(models.Job{}, map[string]interface{}{"gpa": 3.5})
+	assert.Nil(t, err)
+	assert.Empty(t, problems)
+	assert.Nil(t, answers)
+}
+
+// ✅ Test export cells can't be interpreted as spreadsheet formulas
+func TestCSVCell(t *testing.T) {
+	assert.Equal(t, "'=HYPERLINK(\"x\")", csvCell("=HYPERLINK(\"x\")"))
+	assert.Equal(t, "'@SUM(A1)", csvCell("@SUM(A1)"))
+	assert.Equal(t, "Jane Doe", csvCell("Jane Doe"))
+	assert.Equal(t, "", csvCell(""))
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -8,16 +9,27 @@ import (
 
 	"github.com/gatorhire/backend/db"
 	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
 	"github.com/gatorhire/backend/utils"
 	"github.com/google/uuid"
 )
 
+// CreateApplication files an application under the current user's account.
+// Applying needs a session: the account, not an email in the request, is
+// who has to be verified.
 func CreateApplication(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Please log in to apply"})
+		return
+	}
+
 	// Decode the request body into the application struct
 	var app models.Application
-	err := json.NewDecoder(r.Body).Decode(&app)
+	err = json.NewDecoder(r.Body).Decode(&app)
 	if err != nil {
 		log.Printf("❌ Error decoding request body: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
@@ -25,17 +37,41 @@ func CreateApplication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	submitApplication(w, app, userID)
+}
+
+// submitApplication validates and stores an application of the given user,
+// under their account email, writing the response itself
+func submitApplication(w http.ResponseWriter, app models.Application, userID string) {
 	// Validate required fields
-	if app.JobID == "" || app.FullName == "" || app.Email == "" {
-		log.Printf("❌ Missing required fields: JobID: %s, FullName: %s, Email: %s", app.JobID, app.FullName, app.Email)
+	if app.JobID == "" || app.FullName == "" {
+		log.Printf("❌ Missing required fields: JobID: %s, FullName: %s", app.JobID, app.FullName)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Missing required fields"})
 		return
 	}
 
-	// Check if the job exists
+	// Only a verified address proves the applicant owns the account
+	var emailVerified, studentAccess bool
+	err := db.DB.QueryRow(
+		"SELECT email, email_verified, verified_student OR role = 'admin' FROM profiles WHERE id = $1", userID,
+	).Scan(&app.Email, &emailVerified, &studentAccess)
+	if err != nil {
+		log.Printf("❌ Error retrieving applicant %s: %v", userID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "User not found"})
+		return
+	}
+	app.UserID = userID
+	if !emailVerified {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Please verify your email address before applying"})
+		return
+	}
+
+	// Check if the job exists and is open to applicants
 	var jobExists bool
-	query := "SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1)"
+	query := "SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1 AND " + publishedJobCondition + ")"
 	log.Printf("📡 Executing query to check if job exists: %s with JobID: %s", query, app.JobID)
 	err = db.DB.QueryRow(query, app.JobID).Scan(&jobExists)
 	if err != nil {
@@ -52,16 +88,46 @@ func CreateApplication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Enforce what the posting asks applicants to provide
+	job, err := fetchJob(app.JobID)
+	if err != nil {
+		log.Printf("❌ Error fetching job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if problem := screeningProblem(job.Screening, app); problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	// Check the answers to the job's application form
+	answers, problems, err := checkApplicationAnswers(job, app.Answers)
+	if err != nil {
+		log.Printf("❌ Invalid application form on job %s: %v", app.JobID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This job's application form is misconfigured"})
+		return
+	}
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ValidationErrorResponse{Error: "Please correct the highlighted answers", Fields: problems})
+		return
+	}
+	app.Answers = answers
+	app.RenderedAnswers = nil
+
 	// Check if user has already applied to this job
 	var alreadyApplied bool
 	query = `
         SELECT EXISTS(
             SELECT 1 FROM applications 
-            WHERE job_id = $1 AND email = $2
+            WHERE job_id = $1 AND (user_id = $2 OR LOWER(email) = LOWER($3))
         )
     `
 	log.Printf("📡 Executing query to check if user already applied: %s", query)
-	err = db.DB.QueryRow(query, app.JobID, app.Email).Scan(&alreadyApplied)
+	err = db.DB.QueryRow(query, app.JobID, app.UserID, app.Email).Scan(&alreadyApplied)
 	if err != nil {
 		log.Printf("❌ Error checking if user has already applied: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -81,32 +147,31 @@ func CreateApplication(w http.ResponseWriter, r *http.Request) {
 	app.Status = "pending"
 	app.CreatedAt = time.Now()
 
-	// Retrieve the user ID associated with the provided email
-	var userID string
-	userQuery := "SELECT id FROM profiles WHERE email = $1"
-	log.Printf("userquery", userQuery)
-	err = db.DB.QueryRow(userQuery, app.Email).Scan(&userID)
-	if err != nil {
-		log.Printf("❌ Error retrieving user ID: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "User not found"})
+	if job.StudentsOnly && !studentAccess {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This job is only open to verified students"})
 		return
 	}
-	log.Printf("userrid", userID)
-	app.UserID = userID // Set the UserID field
+
+	// Record which revision of the posting the candidate is applying to
+	revisionID, err := liveJobRevision(app.JobID)
+	if err != nil {
+		log.Printf("⚠️ Could not resolve live revision for JobID %s: %v", app.JobID, err)
+	}
+	app.JobRevisionID = revisionID
 
 	// Insert application into the database
 	insertQuery := `
         INSERT INTO applications (
             id, job_id, user_id, full_name, email, phone, 
             cover_letter, resume_url, linkedin, portfolio, 
-            heard_from, created_at, status
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+            heard_from, created_at, status, job_revision_id, answers
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15)
     `
 	log.Printf("📡 Executing insert query: %s", insertQuery)
 	_, err = db.DB.Exec(insertQuery, app.ID, app.JobID, app.UserID, app.FullName, app.Email, app.Phone,
 		app.CoverLetter, app.ResumeURL, app.LinkedIn, app.Portfolio,
-		app.HeardFrom, app.CreatedAt, app.Status)
+		app.HeardFrom, app.CreatedAt, app.Status, app.JobRevisionID, jsonValue(app.Answers))
 
 	if err != nil {
 		log.Printf("❌ Error inserting application into database: %v", err)
@@ -115,6 +180,13 @@ func CreateApplication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	trackJobEvent(app.JobID, jobEventApplication, "")
+
+	// The application replaces any draft the candidate kept for this job
+	if _, err := db.DB.Exec("DELETE FROM application_drafts WHERE user_id = $1 AND job_id = $2", app.UserID, app.JobID); err != nil {
+		log.Printf("⚠️ Error discarding application draft: %v", err)
+	}
+
 	// Return success response
 	log.Printf("✅ Application submitted successfully with ID: %s", app.ID)
 	w.WriteHeader(http.StatusCreated)
@@ -207,18 +279,11 @@ func GetUserApplications(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(applications)
 }
 
-// GetApplicationsByJob returns all applications for a specific job (admin only)
+// GetApplicationsByJob returns all applications for a specific job. Its route
+// requires applications:read for the job.
 func GetApplicationsByJob(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Check if user is admin
-	_, role, err := utils.GetUserFromToken(r)
-	if err != nil || role != "admin" {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
-		return
-	}
-
 	// Get job ID from URL
 	jobID := r.URL.Query().Get("jobId")
 	if jobID == "" {
@@ -227,53 +292,26 @@ func GetApplicationsByJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Query database for job applications
-	rows, err := db.DB.Query(`
-		SELECT a.id, a.job_id, a.user_id, a.full_name, a.email, 
-		       a.phone, a.cover_letter, a.resume_url, a.linkedin, 
-		       a.portfolio, a.heard_from, a.created_at, a.status
-		FROM applications a
-		WHERE a.job_id = $1
-		ORDER BY a.created_at DESC
-	`, jobID)
-
+	// Query database for job applications, with their form answers
+	_, applications, err := fetchJobApplications(jobID)
 	if err != nil {
+		log.Printf("❌ Error fetching applications for job %s: %v", jobID, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
 		return
 	}
-	defer rows.Close()
-
-	// Parse results
-	var applications []models.Application
-	for rows.Next() {
-		var app models.Application
-
-		err := rows.Scan(
-			&app.ID, &app.JobID, &app.UserID, &app.FullName, &app.Email,
-			&app.Phone, &app.CoverLetter, &app.ResumeURL, &app.LinkedIn,
-			&app.Portfolio, &app.HeardFrom, &app.CreatedAt, &app.Status,
-		)
-
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing application data"})
-			return
-		}
-
-		applications = append(applications, app)
-	}
 
 	json.NewEncoder(w).Encode(applications)
 }
 
-// UpdateApplicationStatus updates the status of an application (admin only)
+// UpdateApplicationStatus updates the status of an application
+// (applications:update-status for the application's job)
 func UpdateApplicationStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Check if user is admin
-	_, role, err := utils.GetUserFromToken(r)
-	if err != nil || role != "admin" {
+	// Check if user is logged in
+	_, _, err := utils.GetUserFromToken(r)
+	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
 		return
@@ -307,6 +345,29 @@ func UpdateApplicationStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Status changes are for the application's job; applications that do
+	// not exist are only reported as such to those who may see them all
+	var jobID string
+	err = db.DB.QueryRow("SELECT job_id FROM applications WHERE id = $1", request.ApplicationID).Scan(&jobID)
+	scope := rbac.Scope{}
+	if err == nil {
+		scope, err = rbac.JobScope(jobID)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("❌ Error looking up application %s: %v", request.ApplicationID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !authorize(w, r, rbac.ApplicationsUpdateStatus, scope) {
+		return
+	}
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Application not found"})
+		return
+	}
+
 	// Update application status
 	_, err = db.DB.Exec(
 		"UPDATE applications SET status = $1 WHERE id = $2",
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// companyColumns is the column list scanCompany expects, in order
+const companyColumns = `id, name, slug, description, COALESCE(logo_url, ''), COALESCE(website, ''),
+	COALESCE(industry, ''), COALESCE(size, ''), created_at`
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// companySlug derives the URL slug of a company name, matching the
+// expression used by db/migrations/005_companies.sql
+func companySlug(name string) string {
+	return strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// scanCompany reads a companies row selected with companyColumns
+func scanCompany(row rowScanner) (models.Company, error) {
+	var company models.Company
+	err := row.Scan(
+		&company.ID, &company.Name, &company.Slug, &company.Description, &company.LogoURL,
+		&company.Website, &company.Industry, &company.Size, &company.CreatedAt,
+	)
+	return company, err
+}
+
+// resolveCompanyID returns the company profile a job belongs to: the given ID
+// if it exists, otherwise the company whose slug matches the job's company
+// name. It returns nil (SQL NULL) when there is no such profile.
+func resolveCompanyID(companyID, companyName string) (interface{}, error) {
+	var id string
+	var err error
+	if companyID != "" {
+		err = db.DB.QueryRow("SELECT id FROM companies WHERE id = $1", companyID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("company %s does not exist", companyID)
+		}
+	} else {
+		err = db.DB.QueryRow("SELECT id FROM companies WHERE slug = $1", companySlug(companyName)).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// authorize lets the request through if the user holds the permission in
+// scope, and the API key it was made with, if any, has it in its scopes. It
+// writes the error response itself and returns false when access is denied.
+func authorize(w http.ResponseWriter, r *http.Request, permission rbac.Permission, scope rbac.Scope) bool {
+	claims, err := utils.GetClaimsFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return false
+	}
+	if !claims.ScopeAllows(permission) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This API key is not allowed to " + string(permission)})
+		return false
+	}
+
+	allowed, err := rbac.Authorize(claims.UserID, claims.Role, permission, scope)
+	if err != nil {
+		log.Printf("❌ Error checking permission %s: %v", permission, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return false
+	}
+	if !allowed {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Forbidden"})
+		return false
+	}
+	return true
+}
+
+// validateCompany trims and checks a company profile submitted by a user
+func validateCompany(company *models.Company) string {
+	company.Name = strings.TrimSpace(company.Name)
+	company.Slug = companySlug(company.Name)
+	if company.Name == "" || company.Slug == "" {
+		return "Company name is required"
+	}
+	for _, link := range []string{company.Website, company.LogoURL} {
+		if link != "" && !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+			return "Website and logo must be http(s) URLs"
+		}
+	}
+	return ""
+}
+
+// GetCompanies lists company profiles, optionally filtered by a name query
+func GetCompanies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := "SELECT " + companyColumns + " FROM companies"
+	var args []interface{}
+	if q := r.URL.Query().Get("q"); q != "" {
+		query += " WHERE name ILIKE $1"
+		args = append(args, "%"+q+"%")
+	}
+	query += " ORDER BY name"
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		log.Printf("❌ Error querying companies: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	companies := []models.Company{}
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing company data"})
+			return
+		}
+		companies = append(companies, company)
+	}
+
+	json.NewEncoder(w).Encode(companies)
+}
+
+// GetCompany returns a public company page, by ID or slug, with its open jobs
+func GetCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idOrSlug := mux.Vars(r)["id"]
+	company, err := scanCompany(db.DB.QueryRow(
+		"SELECT "+companyColumns+" FROM companies WHERE id = $1 OR slug = $1", idOrSlug,
+	))
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Company not found"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error fetching company: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	rows, err := db.DB.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE company_id = $1 AND "+visibleJobCondition(viewerHasStudentAccess(r))+" ORDER BY posted_date DESC",
+		company.ID,
+	)
+	if err != nil {
+		log.Printf("❌ Error querying company jobs: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	company.OpenJobs = []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing job data"})
+			return
+		}
+		company.OpenJobs = append(company.OpenJobs, job)
+	}
+
+	json.NewEncoder(w).Encode(company)
+}
+
+// CreateCompany creates a company profile (admin only)
+func CreateCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var company models.Company
+	if err := json.NewDecoder(r.Body).Decode(&company); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if problem := validateCompany(&company); problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	company.ID = uuid.New().String()
+	company.CreatedAt = time.Now()
+	_, err := db.DB.Exec(`
+		INSERT INTO companies (id, name, slug, description, logo_url, website, industry, size, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9)
+	`, company.ID, company.Name, company.Slug, company.Description, company.LogoURL,
+		company.Website, company.Industry, company.Size, company.CreatedAt)
+	if err != nil {
+		log.Printf("❌ Error creating company: %v", err)
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A company with this name already exists"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(company)
+}
+
+// UpdateCompany edits a company profile (company:update)
+func UpdateCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	companyID := mux.Vars(r)["id"]
+	if !authorize(w, r, rbac.CompanyUpdate, rbac.Scope{CompanyID: companyID}) {
+		return
+	}
+
+	var company models.Company
+	if err := json.NewDecoder(r.Body).Decode(&company); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if problem := validateCompany(&company); problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE companies SET
+			name = $2, slug = $3, description = $4, logo_url = NULLIF($5, ''),
+			website = NULLIF($6, ''), industry = NULLIF($7, ''), size = NULLIF($8, '')
+		WHERE id = $1
+	`, companyID, company.Name, company.Slug, company.Description, company.LogoURL,
+		company.Website, company.Industry, company.Size)
+	if err != nil {
+		log.Printf("❌ Error updating company: %v", err)
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A company with this name already exists"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Company not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// GetCompanyMembers lists the recruiter accounts of a company (company:read)
+func GetCompanyMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	companyID := mux.Vars(r)["id"]
+	if !authorize(w, r, rbac.CompanyRead, rbac.Scope{CompanyID: companyID}) {
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT m.company_id, m.user_id, p.email, p.full_name, m.role, m.created_at
+		FROM company_members m
+		JOIN profiles p ON p.id = m.user_id
+		WHERE m.company_id = $1
+		ORDER BY m.created_at
+	`, companyID)
+	if err != nil {
+		log.Printf("❌ Error querying company members: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	members := []models.CompanyMember{}
+	for rows.Next() {
+		var member models.CompanyMember
+		err := rows.Scan(&member.CompanyID, &member.UserID, &member.Email, &member.FullName, &member.Role, &member.CreatedAt)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing member data"})
+			return
+		}
+		members = append(members, member)
+	}
+
+	json.NewEncoder(w).Encode(members)
+}
+
+// AddCompanyMember adds an account to a company by email, with a company
+// role (company:members)
+func AddCompanyMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	companyID := mux.Vars(r)["id"]
+	if !authorize(w, r, rbac.CompanyMembers, rbac.Scope{CompanyID: companyID}) {
+		return
+	}
+
+	var request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Email == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if request.Role == "" {
+		request.Role = "recruiter"
+	}
+	if !rbac.ValidRole(rbac.CompanyRoles, request.Role) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Role must be owner, recruiter, hiring_manager or interviewer"})
+		return
+	}
+
+	var userID string
+	err := db.DB.QueryRow("SELECT id FROM profiles WHERE LOWER(email) = LOWER($1)", strings.TrimSpace(request.Email)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "User not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO company_members (company_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (company_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, companyID, userID, request.Role)
+	if err != nil {
+		log.Printf("❌ Error adding company member: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to add member"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// RemoveCompanyMember removes an account from a company (company:members)
+func RemoveCompanyMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	if !authorize(w, r, rbac.CompanyMembers, rbac.Scope{CompanyID: vars["id"]}) {
+		return
+	}
+
+	result, err := db.DB.Exec(
+		"DELETE FROM company_members WHERE company_id = $1 AND user_id = $2",
+		vars["id"], vars["userId"],
+	)
+	if err != nil {
+		log.Printf("❌ Error removing company member: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to remove member"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Member not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gatorhire/backend/models"
+)
+
+// ✅ Test company slugs collapse punctuation and case
+func TestCompanySlug(t *testing.T) {
+	assert.Equal(t, "acme-inc", companySlug("Acme, Inc."))
+	assert.Equal(t, "acme-inc", companySlug("  ACME   Inc"))
+	assert.Equal(t, "data-systems-2", companySlug("Data Systems #2"))
+}
+
+// ✅ Test company profile validation
+func TestValidateCompany(t *testing.T) {
+	company := models.Company{Name: " TechCorp ", Website: "https://techcorp.example"}
+	assert.Equal(t, "", validateCompany(&company))
+	assert.Equal(t, "TechCorp", company.Name)
+	assert.Equal(t, "techcorp", company.Slug)
+
+	assert.NotEqual(t, "", validateCompany(&models.Company{Name: "!!!"}))
+	assert.NotEqual(t, "", validateCompany(&models.Company{Name: "TechCorp", LogoURL: "javascript:alert(1)"}))
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"log"
+	"os"
+
+	"github.com/gatorhire/backend/mailer"
+)
+
+// outbox delivers the emails handlers send. It prints to the console until
+// main configures the real mailer with SetMailer.
+var outbox mailer.Mailer = &mailer.Console{Out: os.Stdout}
+
+// SetMailer sets how emails are delivered
+func SetMailer(m mailer.Mailer) {
+	outbox = m
+}
+
+// sendEmail delivers a message in the background, so responses don't wait
+// on (or reveal anything through the timing of) the mail server
+func sendEmail(msg mailer.Message) {
+	go func() {
+		if err := outbox.Send(msg); err != nil {
+			log.Printf("❌ Error sending %q email to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/mailer"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+)
+
+// emailVerificationTTL is how long a verification link works
+const emailVerificationTTL = 48 * time.Hour
+
+// Verification emails can be resent once a minute, and five times a day
+const (
+	verificationResendInterval = time.Minute
+	maxVerificationsPerDay     = 5
+)
+
+// Verification links either confirm the account's own email address or a
+// university address that makes the account a verified student
+const (
+	verifyAccountEmail = "account"
+	verifyStudentEmail = "student"
+)
+
+// createEmailVerification stores a new verification link for the address
+// and returns its token
+func createEmailVerification(exec queryExecer, userID, email, purpose string) (string, error) {
+	token := newToken()
+	_, err := exec.Exec(`
+		INSERT INTO email_verifications (id, user_id, email, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, uuid.New().String(), userID, email, purpose, hashSecretToken(token), time.Now().Add(emailVerificationTTL))
+	return token, err
+}
+
+// sendVerificationEmail emails a link that confirms the user owns the
+// address. Register should call it for every new account.
+func sendVerificationEmail(exec queryExecer, userID, email, fullName string) error {
+	token, err := createEmailVerification(exec, userID, email, verifyAccountEmail)
+	if err != nil {
+		return err
+	}
+
+	sendEmail(mailer.Message{
+		To:      email,
+		Subject: "Confirm your GatorHire email address",
+		Body: "Hi " + fullName + ",\n\n" +
+			"Please confirm this is your email address to finish setting up your GatorHire account:\n\n" +
+			apiBaseURL() + "/auth/verify-email?token=" + url.QueryEscape(token) + "\n\n" +
+			"The link expires in two days. If you didn't sign up, you can ignore this email.\n",
+	})
+	return nil
+}
+
+// verifyEmail marks the address a verification token was sent to as
+// verified. Account addresses on a trusted university domain make the user
+// a verified student as well. It returns sql.ErrNoRows for unknown, used or
+// expired tokens, and for account tokens sent to an address the account no
+// longer uses.
+//
+// When another account is already a student through the address, student
+// links fail with errStudentEmailClaimed and stay unused. Account addresses
+// are still verified, and claimed reports that the user did not become a
+// student.
+func verifyEmail(token string) (userID string, claimed bool, err error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return "", false, err
+	}
+	defer tx.Rollback()
+
+	var email, purpose string
+	err = tx.QueryRow(`
+		SELECT v.user_id, v.email, v.purpose FROM email_verifications v
+		JOIN profiles p ON p.id = v.user_id
+		WHERE v.token_hash = $1 AND v.used_at IS NULL AND v.expires_at > NOW()
+		  AND (v.purpose = 'student' OR p.email = v.email)
+		FOR UPDATE OF v
+	`, hashSecretToken(token)).Scan(&userID, &email, &purpose)
+	if err != nil {
+		return "", false, err
+	}
+
+	if purpose == verifyAccountEmail {
+		_, err = tx.Exec("UPDATE profiles SET email_verified = TRUE WHERE id = $1", userID)
+	}
+	if err == nil && (purpose == verifyStudentEmail || isStudentEmail(email)) {
+		err = markVerifiedStudent(tx, userID, email)
+		if err == errStudentEmailClaimed && purpose == verifyAccountEmail {
+			claimed, err = true, nil
+		}
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := tx.Exec("UPDATE email_verifications SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL", userID, purpose); err != nil {
+		return "", false, err
+	}
+	return userID, claimed, tx.Commit()
+}
+
+// VerifyEmail handles the link from the verification email. Browsers are
+// sent on to the site's login page with the outcome in ?verified=, plus
+// ?student_error=email_in_use when the address could not make the user a
+// student; API clients asking for JSON get it directly.
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	wantsJSON := r.Header.Get("Accept") == "application/json"
+
+	verified, claimed := false, false
+	if token != "" {
+		userID, studentClaimed, err := verifyEmail(token)
+		claimed = studentClaimed || err == errStudentEmailClaimed
+		if err == nil {
+			log.Printf("✅ Email verified for user %s", userID)
+			verified = true
+		} else if err != sql.ErrNoRows && err != errStudentEmailClaimed {
+			log.Printf("❌ Error verifying email: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+			return
+		}
+	}
+
+	if !wantsJSON {
+		target := siteURL() + "/login?verified=" + strconv.FormatBool(verified)
+		if claimed {
+			target += "&student_error=email_in_use"
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if claimed {
+		message := "This university address is already used by another account"
+		if verified {
+			message = "Your email address is verified, but it is already used by another account as a student address"
+		}
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: message})
+		return
+	}
+	if !verified {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This verification link is invalid or has expired"})
+		return
+	}
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// ResendVerificationEmail sends the current user a new verification link
+func ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var email, fullName string
+	var verified bool
+	err = db.DB.QueryRow("SELECT email, full_name, email_verified FROM profiles WHERE id = $1", userID).
+		Scan(&email, &fullName, &verified)
+	if err != nil {
+		log.Printf("❌ Error fetching profile for verification: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if verified {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Your email address is already verified"})
+		return
+	}
+
+	var sentToday int
+	var lastSent sql.NullTime
+	err = db.DB.QueryRow(
+		"SELECT COUNT(*), MAX(created_at) FROM email_verifications WHERE user_id = $1 AND purpose = $2 AND created_at > $3",
+		userID, verifyAccountEmail, time.Now().Add(-24*time.Hour),
+	).Scan(&sentToday, &lastSent)
+	if err != nil {
+		log.Printf("❌ Error counting verification emails: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if wait := verificationResendWait(sentToday, lastSent, time.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Please wait before requesting another verification email"})
+		return
+	}
+
+	if err := sendVerificationEmail(db.DB, userID, email, fullName); err != nil {
+		log.Printf("❌ Error sending verification email: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to send verification email"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// verificationResendWait returns how long a user must wait before another
+// verification email, given how many were sent in the last day and when
+// the latest one was
+func verificationResendWait(sentToday int, lastSent sql.NullTime, now time.Time) time.Duration {
+	if !lastSent.Valid {
+		return 0
+	}
+	if sentToday >= maxVerificationsPerDay {
+		// A day after the latest one is a safe upper bound
+		return lastSent.Time.Add(24 * time.Hour).Sub(now)
+	}
+	if wait := lastSent.Time.Add(verificationResendInterval).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test verification emails are throttled per minute and per day
+func TestVerificationResendWait(t *testing.T) {
+	now := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	sentAt := func(ago time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(-ago), Valid: true} }
+
+	assert.Equal(t, time.Duration(0), verificationResendWait(0, sql.NullTime{}, now))
+	assert.Equal(t, 45*time.Second, verificationResendWait(1, sentAt(15*time.Second), now))
+	assert.Equal(t, time.Duration(0), verificationResendWait(4, sentAt(2*time.Minute), now))
+	assert.Equal(t, 22*time.Hour, verificationResendWait(maxVerificationsPerDay, sentAt(2*time.Hour), now))
+}
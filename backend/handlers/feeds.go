@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// feedSize is the number of most recent jobs included in a feed
+	feedSize = 50
+
+	// jobPostingValidity is how long a posting is advertised as valid to
+	// search engines, counted from its posted date
+	jobPostingValidity = 60 * 24 * time.Hour
+)
+
+// siteURL returns the public URL of the frontend, used for links in feeds
+func siteURL() string {
+	if url := os.Getenv("PUBLIC_SITE_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:5173"
+}
+
+// jobURL returns the public job details page for a job
+func jobURL(jobID string) string {
+	return siteURL() + "/jobs/" + jobID
+}
+
+// RSS 2.0 document structure
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Category    string  `xml:"category,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// Atom 1.0 document structure
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string        `xml:"title"`
+	ID        string        `xml:"id"`
+	Updated   string        `xml:"updated"`
+	Published string        `xml:"published"`
+	Links     []atomLink    `xml:"link"`
+	Summary   string        `xml:"summary"`
+	Author    atomAuthor    `xml:"author"`
+	Category  *atomCategory `xml:"category,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// feedFilterError reports query parameters a feed cannot be filtered by
+type feedFilterError struct{ error }
+
+// fetchFeedJobs returns the newest active jobs, optionally narrowed by the
+// category, location, workArrangement, remoteRegion and skills query parameters
+func fetchFeedJobs(r *http.Request) ([]models.Job, error) {
+	params := r.URL.Query()
+	// Feeds are public, so student-only jobs never appear in them
+	conditions := []string{visibleJobCondition(false)}
+	var args []interface{}
+
+	if category := params.Get("category"); category != "" && category != "All" {
+		args = append(args, category)
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(args)))
+	}
+	if location := params.Get("location"); location != "" {
+		args = append(args, "%"+location+"%")
+		conditions = append(conditions, fmt.Sprintf("location ILIKE $%d", len(args)))
+	}
+	conditions, args, err := appendWorkArrangementFilter(params, conditions, args)
+	if err != nil {
+		return nil, feedFilterError{err}
+	}
+	conditions, args, err = appendSkillFilter(params, conditions, args)
+	if err != nil {
+		if _, unknown := err.(unknownSkillError); unknown {
+			err = feedFilterError{err}
+		}
+		return nil, err
+	}
+
+	rows, err := db.DB.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE "+strings.Join(conditions, " AND ")+
+			fmt.Sprintf(" ORDER BY posted_date DESC LIMIT %d", feedSize),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// feedSummary is the one-line description of a job used in feeds
+func feedSummary(job models.Job) string {
+	return fmt.Sprintf("%s · %s · %s — %s", job.Company, job.Location, job.Type, job.Description)
+}
+
+// GetJobsRSS serves published jobs as an RSS 2.0 feed
+func GetJobsRSS(w http.ResponseWriter, r *http.Request) {
+	jobs, err := fetchFeedJobs(r)
+	if _, invalid := err.(feedFilterError); invalid {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("❌ Error querying jobs for RSS feed: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         "GatorHire Jobs",
+			Link:          siteURL() + "/jobs",
+			Description:   "The latest job postings on GatorHire",
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, job := range jobs {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       job.Title + " at " + job.Company,
+			Link:        jobURL(job.ID),
+			Description: feedSummary(job),
+			GUID:        rssGUID{IsPermaLink: true, Value: jobURL(job.ID)},
+			PubDate:     job.PostedDate.UTC().Format(time.RFC1123Z),
+			Category:    job.Category,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(feed)
+}
+
+// GetJobsAtom serves published jobs as an Atom 1.0 feed
+func GetJobsAtom(w http.ResponseWriter, r *http.Request) {
+	jobs, err := fetchFeedJobs(r)
+	if _, invalid := err.(feedFilterError); invalid {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("❌ Error querying jobs for Atom feed: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// An Atom feed is as fresh as its newest entry
+	updated := time.Now().UTC()
+	if len(jobs) > 0 {
+		updated = jobs[0].PostedDate.UTC()
+	}
+
+	feed := atomFeed{
+		Title:   "GatorHire Jobs",
+		ID:      siteURL() + "/jobs",
+		Updated: updated.Format(time.RFC3339),
+		Links:   []atomLink{{Href: siteURL() + "/jobs", Rel: "alternate"}},
+	}
+	for _, job := range jobs {
+		entry := atomEntry{
+			Title:     job.Title + " at " + job.Company,
+			ID:        jobURL(job.ID),
+			Updated:   job.PostedDate.UTC().Format(time.RFC3339),
+			Published: job.PostedDate.UTC().Format(time.RFC3339),
+			Links:     []atomLink{{Href: jobURL(job.ID), Rel: "alternate"}},
+			Summary:   feedSummary(job),
+			Author:    atomAuthor{Name: job.Company},
+		}
+		if job.Category != "" {
+			entry.Category = &atomCategory{Term: job.Category}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(feed)
+}
+
+// GetJobPostingJSONLD returns a job as a schema.org JobPosting for search
+// engines and aggregators
+func GetJobPostingJSONLD(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/ld+json")
+
+	job, err := fetchJob(mux.Vars(r)["id"])
+	if err != nil || !jobVisibleTo(job, false) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(jobPostingJSONLD(job))
+}
+
+// jobPostingJSONLD maps a job onto the schema.org JobPosting vocabulary
+func jobPostingJSONLD(job models.Job) map[string]interface{} {
+	organization := map[string]interface{}{
+		"@type": "Organization",
+		"name":  job.Company,
+	}
+	if info := job.CompanyInfo; info != nil {
+		if info.Name != "" {
+			organization["name"] = info.Name
+		}
+		if info.Website != "" {
+			organization["sameAs"] = info.Website
+		}
+		if info.Description != "" {
+			organization["description"] = info.Description
+		}
+	}
+
+	description := job.Description
+	for _, section := range []struct {
+		heading string
+		items   []string
+	}{
+		{"Responsibilities", job.Responsibilities},
+		{"Requirements", job.Requirements},
+		{"Benefits", job.Benefits},
+	} {
+		if len(section.items) > 0 {
+			description += "\n\n" + section.heading + ":\n- " + strings.Join(section.items, "\n- ")
+		}
+	}
+
+	posting := map[string]interface{}{
+		"@context":           "https://schema.org/",
+		"@type":              "JobPosting",
+		"title":              job.Title,
+		"description":        description,
+		"datePosted":         job.PostedDate.Format("2006-01-02"),
+		"validThrough":       job.PostedDate.Add(jobPostingValidity).Format(time.RFC3339),
+		"employmentType":     schemaEmploymentType(job.Type),
+		"hiringOrganization": organization,
+		"industry":           job.Category,
+		"url":                jobURL(job.ID),
+		"identifier": map[string]interface{}{
+			"@type": "PropertyValue",
+			"name":  organization["name"],
+			"value": job.ID,
+		},
+	}
+
+	if job.WorkArrangement == models.WorkArrangementRemote {
+		posting["jobLocationType"] = "TELECOMMUTE"
+		var requirements []map[string]interface{}
+		for _, region := range job.RemoteRegions {
+			requirements = append(requirements, map[string]interface{}{"@type": "Country", "name": region})
+		}
+		if requirements != nil {
+			posting["applicantLocationRequirements"] = requirements
+		}
+	} else {
+		posting["jobLocation"] = map[string]interface{}{
+			"@type":   "Place",
+			"address": schemaPostalAddress(job.Location),
+		}
+	}
+
+	if salary := schemaBaseSalary(job.Salary); salary != nil {
+		posting["baseSalary"] = salary
+	}
+
+	return posting
+}
+
+// schemaEmploymentType maps a job type onto schema.org's employment type enumeration
+func schemaEmploymentType(jobType string) string {
+	switch strings.ToLower(strings.ReplaceAll(jobType, "-", " ")) {
+	case "full time":
+		return "FULL_TIME"
+	case "part time":
+		return "PART_TIME"
+	case "contract", "contractor", "freelance":
+		return "CONTRACTOR"
+	case "internship", "intern", "co op":
+		return "INTERN"
+	case "temporary", "seasonal":
+		return "TEMPORARY"
+	case "volunteer":
+		return "VOLUNTEER"
+	}
+	return "OTHER"
+}
+
+// schemaPostalAddress turns "City, ST" into a schema.org PostalAddress
+func schemaPostalAddress(location string) map[string]interface{} {
+	address := map[string]interface{}{"@type": "PostalAddress"}
+	parts := strings.Split(location, ",")
+	address["addressLocality"] = strings.TrimSpace(parts[0])
+	if len(parts) > 1 {
+		address["addressRegion"] = strings.TrimSpace(parts[1])
+	}
+	address["addressCountry"] = "US"
+	if len(parts) > 2 {
+		address["addressCountry"] = strings.TrimSpace(parts[2])
+	}
+	return address
+}
+
+var salaryAmountPattern = regexp.MustCompile(`\$?\s*(\d[\d,]*(?:\.\d+)?)\s*([kK])?`)
+
+// parseSalary extracts up to two amounts (a single figure or a range) and the
+// pay period from salaries such as "$120,000 - $150,000", "$90k" or
+// "$18/hour". It returns no amounts when the salary has no numbers in it.
+func parseSalary(salary string) ([]float64, string) {
+	var amounts []float64
+	for _, match := range salaryAmountPattern.FindAllStringSubmatch(salary, 2) {
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+		if err != nil {
+			return nil, ""
+		}
+		if match[2] != "" {
+			amount *= 1000
+		}
+		amounts = append(amounts, amount)
+	}
+
+	lower := strings.ToLower(salary)
+	switch {
+	case strings.Contains(lower, "hour") || strings.Contains(lower, "/hr"):
+		return amounts, "HOUR"
+	case strings.Contains(lower, "month"):
+		return amounts, "MONTH"
+	case strings.Contains(lower, "week"):
+		return amounts, "WEEK"
+	}
+	return amounts, "YEAR"
+}
+
+// schemaBaseSalary turns a salary into a schema.org MonetaryAmount. It
+// returns nil when no amount can be found.
+func schemaBaseSalary(salary string) map[string]interface{} {
+	amounts, unit := parseSalary(salary)
+	if len(amounts) == 0 {
+		return nil
+	}
+
+	value := map[string]interface{}{"@type": "QuantitativeValue", "unitText": unit}
+	if len(amounts) == 2 {
+		value["minValue"] = amounts[0]
+		value["maxValue"] = amounts[1]
+	} else {
+		value["value"] = amounts[0]
+	}
+
+	return map[string]interface{}{
+		"@type":    "MonetaryAmount",
+		"currency": "USD",
+		"value":    value,
+	}
+}
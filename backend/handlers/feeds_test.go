@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gatorhire/backend/models"
+)
+
+// ✅ Test mapping a job onto a schema.org JobPosting
+func TestJobPostingJSONLD(t *testing.T) {
+	job := models.Job{
+		ID:              "42",
+		Title:           "Backend Engineer",
+		Company:         "DataSystems",
+		Location:        "New York, NY",
+		Type:            "Full-time",
+		Salary:          "$130,000 - $160,000",
+		Description:     "Build APIs",
+		Requirements:    []string{"Go"},
+		PostedDate:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Category:        "Technology",
+		WorkArrangement: models.WorkArrangementOnsite,
+		CompanyInfo:     &models.CompanyInfo{Name: "DataSystems Inc.", Website: "https://datasystems.example"},
+	}
+
+	posting := jobPostingJSONLD(job)
+	assert.Equal(t, "JobPosting", posting["@type"])
+	assert.Equal(t, "FULL_TIME", posting["employmentType"])
+	assert.Equal(t, "2025-03-01", posting["datePosted"])
+	assert.Equal(t, "2025-04-30T00:00:00Z", posting["validThrough"])
+
+	organization := posting["hiringOrganization"].(map[string]interface{})
+	assert.Equal(t, "DataSystems Inc.", organization["name"])
+	assert.Equal(t, "https://datasystems.example", organization["sameAs"])
+
+	address := posting["jobLocation"].(map[string]interface{})["address"].(map[string]interface{})
+	assert.Equal(t, "New York", address["addressLocality"])
+	assert.Equal(t, "NY", address["addressRegion"])
+
+	salary := posting["baseSalary"].(map[string]interface{})["value"].(map[string]interface{})
+	assert.Equal(t, 130000.0, salary["minValue"])
+	assert.Equal(t, 160000.0, salary["maxValue"])
+	assert.Equal(t, "YEAR", salary["unitText"])
+}
+
+// ✅ Test salary parsing for hourly and unparseable values
+func TestSchemaBaseSalary(t *testing.T) {
+	hourly := schemaBaseSalary("$18/hour")["value"].(map[string]interface{})
+	assert.Equal(t, 18.0, hourly["value"])
+	assert.Equal(t, "HOUR", hourly["unitText"])
+
+	assert.Nil(t, schemaBaseSalary("Competitive"))
+}
+
+// ✅ Test feeds refuse unknown work arrangements before querying
+func TestFeedRejectsInvalidWorkArrangement(t *testing.T) {
+	rr := httptest.NewRecorder()
+	GetJobsRSS(rr, httptest.NewRequest("GET", "/feeds/jobs.rss?workArrangement=moon", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gorilla/mux"
+)
+
+// hiringTeamJobExists writes a 404 and returns false if the job in the URL
+// does not exist. The routes check permissions first, so only those allowed
+// to manage a job's team learn whether it exists.
+func hiringTeamJobExists(w http.ResponseWriter, jobID string) bool {
+	var exists bool
+	if err := db.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1)", jobID).Scan(&exists); err != nil {
+		log.Printf("❌ Error checking job %s: %v", jobID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return false
+	}
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+	}
+	return exists
+}
+
+// GetHiringTeam lists the accounts given a role on a job (jobs:read)
+func GetHiringTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["id"]
+	if !hiringTeamJobExists(w, jobID) {
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT m.job_id, m.user_id, p.email, p.full_name, m.role, m.created_at
+		FROM job_members m
+		JOIN profiles p ON p.id = m.user_id
+		WHERE m.job_id = $1
+		ORDER BY m.created_at
+	`, jobID)
+	if err != nil {
+		log.Printf("❌ Error querying hiring team: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	members := []models.HiringTeamMember{}
+	for rows.Next() {
+		var member models.HiringTeamMember
+		err := rows.Scan(&member.JobID, &member.UserID, &member.Email, &member.FullName, &member.Role, &member.CreatedAt)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing member data"})
+			return
+		}
+		members = append(members, member)
+	}
+
+	json.NewEncoder(w).Encode(members)
+}
+
+// AddHiringTeamMember gives an account a role on a job by email, or changes
+// the role it has (jobs:team)
+func AddHiringTeamMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["id"]
+	var request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Email == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if request.Role == "" {
+		request.Role = rbac.RoleInterviewer
+	}
+	if !rbac.ValidRole(rbac.JobRoles, request.Role) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Role must be recruiter, hiring_manager or interviewer"})
+		return
+	}
+	if !hiringTeamJobExists(w, jobID) {
+		return
+	}
+
+	var userID string
+	err := db.DB.QueryRow("SELECT id FROM profiles WHERE LOWER(email) = LOWER($1)", strings.TrimSpace(request.Email)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "User not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO job_members (job_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (job_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, jobID, userID, request.Role)
+	if err != nil {
+		log.Printf("❌ Error adding hiring team member: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to add member"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// RemoveHiringTeamMember takes an account off a job's hiring team (jobs:team)
+func RemoveHiringTeamMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	result, err := db.DB.Exec(
+		"DELETE FROM job_members WHERE job_id = $1 AND user_id = $2",
+		vars["id"], vars["userId"],
+	)
+	if err != nil {
+		log.Printf("❌ Error removing hiring team member: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to remove member"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Member not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
@@ -0,0 +1,624 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/mailer"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+var validAlertFrequencies = map[string]bool{
+	"instant": true,
+	"daily":   true,
+	"weekly":  true,
+}
+
+// savedSearchColumns is the column list scanSavedSearch expects, in order
+const savedSearchColumns = `id, user_id, name, COALESCE(keywords, ''), COALESCE(category, ''),
+	COALESCE(type, ''), COALESCE(location, ''), COALESCE(min_salary, 0), frequency, muted,
+	unsubscribe_token, created_at, last_matched_at`
+
+// apiBaseURL returns the public URL of this API, used for links in emails
+func apiBaseURL() string {
+	if url := os.Getenv("PUBLIC_API_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:8083/api"
+}
+
+// newToken returns a random 32-byte token, hex encoded
+func newToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// scanSavedSearch reads a saved_searches row selected with savedSearchColumns
+func scanSavedSearch(row rowScanner) (models.SavedSearch, error) {
+	var search models.SavedSearch
+	var token string
+	err := row.Scan(
+		&search.ID, &search.UserID, &search.Name, &search.Keywords, &search.Category,
+		&search.Type, &search.Location, &search.MinSalary, &search.Frequency, &search.Muted,
+		&token, &search.CreatedAt, &search.LastMatchedAt,
+	)
+	search.UnsubscribeURL = apiBaseURL() + "/alerts/unsubscribe?token=" + url.QueryEscape(token)
+	return search, err
+}
+
+// validateSavedSearch checks a saved search submitted by a user, filling in defaults
+func validateSavedSearch(search *models.SavedSearch) string {
+	search.Name = strings.TrimSpace(search.Name)
+	if search.Frequency == "" {
+		search.Frequency = "daily"
+	}
+
+	switch {
+	case search.Name == "":
+		return "Name is required"
+	case !validAlertFrequencies[search.Frequency]:
+		return "Frequency must be instant, daily or weekly"
+	case search.MinSalary < 0:
+		return "Minimum salary cannot be negative"
+	case search.Keywords == "" && search.Category == "" && search.Type == "" &&
+		search.Location == "" && search.MinSalary == 0:
+		return "At least one search criterion is required"
+	}
+	return ""
+}
+
+// GetSavedSearches returns the authenticated user's job alerts
+func GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	rows, err := db.DB.Query(
+		"SELECT "+savedSearchColumns+" FROM saved_searches WHERE user_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		log.Printf("❌ Error querying saved searches: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing saved search data"})
+			return
+		}
+		searches = append(searches, search)
+	}
+
+	json.NewEncoder(w).Encode(searches)
+}
+
+// CreateSavedSearch saves a search and starts alerting the user about new matches
+func CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var search models.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if problem := validateSavedSearch(&search); problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	// Only jobs published from now on are matched
+	created, err := scanSavedSearch(db.DB.QueryRow(`
+		INSERT INTO saved_searches (
+			id, user_id, name, keywords, category, type, location, min_salary,
+			frequency, muted, unsubscribe_token, last_matched_at, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
+		RETURNING `+savedSearchColumns,
+		uuid.New().String(), userID, search.Name, search.Keywords, search.Category, search.Type,
+		search.Location, search.MinSalary, search.Frequency, search.Muted, newToken(),
+	))
+	if err != nil {
+		log.Printf("❌ Error saving search: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save search"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateSavedSearch changes a job alert's criteria, frequency or muted flag
+func UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var search models.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if problem := validateSavedSearch(&search); problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE saved_searches SET
+			name = $3, keywords = $4, category = $5, type = $6, location = $7,
+			min_salary = $8, frequency = $9, muted = $10
+		WHERE id = $1 AND user_id = $2
+	`, mux.Vars(r)["id"], userID, search.Name, search.Keywords, search.Category, search.Type,
+		search.Location, search.MinSalary, search.Frequency, search.Muted)
+	if err != nil {
+		log.Printf("❌ Error updating saved search: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update saved search"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Saved search not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// MuteSavedSearch pauses or resumes a job alert without changing its criteria
+func MuteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Muted bool `json:"muted"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	result, err := db.DB.Exec(
+		"UPDATE saved_searches SET muted = $3 WHERE id = $1 AND user_id = $2",
+		mux.Vars(r)["id"], userID, request.Muted,
+	)
+	if err != nil {
+		log.Printf("❌ Error muting saved search: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update saved search"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Saved search not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// DeleteSavedSearch removes a job alert and any notifications still queued for it
+func DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	result, err := db.DB.Exec(
+		"DELETE FROM saved_searches WHERE id = $1 AND user_id = $2",
+		mux.Vars(r)["id"], userID,
+	)
+	if err != nil {
+		log.Printf("❌ Error deleting saved search: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to delete saved search"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Saved search not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// UnsubscribeSavedSearch mutes a job alert using the token from an alert
+// email, so it works without logging in. Following the link only mutes the
+// alert, since mail scanners and link prefetchers follow links too; it can
+// be unmuted from the site. DeleteSavedSearchByToken removes it for good.
+func UnsubscribeSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Token is required"})
+		return
+	}
+
+	result, err := db.DB.Exec("UPDATE saved_searches SET muted = TRUE WHERE unsubscribe_token = $1", token)
+	if err != nil {
+		log.Printf("❌ Error unsubscribing saved search: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Alert not found or already deleted"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// DeleteSavedSearchByToken deletes a job alert using the token from an alert
+// email. It only answers POST, so following the link can't delete anything;
+// the token may come in the query string or a form body.
+func DeleteSavedSearchByToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token := r.FormValue("token")
+	if token == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Token is required"})
+		return
+	}
+
+	result, err := db.DB.Exec("DELETE FROM saved_searches WHERE unsubscribe_token = $1", token)
+	if err != nil {
+		log.Printf("❌ Error deleting saved search: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Alert not found or already deleted"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// StartAlertMatcher runs the saved search matcher in the background every
+// interval, then emails the notifications that are due
+func StartAlertMatcher(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			if err := matchSavedSearches(now); err != nil {
+				log.Printf("❌ Alert matcher failed: %v", err)
+			}
+			if err := deliverJobAlerts(now); err != nil {
+				log.Printf("❌ Alert delivery failed: %v", err)
+			}
+		}
+	}()
+}
+
+// matchSavedSearches evaluates jobs published since each search was last
+// matched and queues a notification per match. A job counts from when it
+// became public (published_at), not its posting date, so approved
+// submissions, restored jobs and backdated imports still alert. Muted
+// searches are skipped but still moved forward, so unmuting does not replay
+// everything missed.
+func matchSavedSearches(now time.Time) error {
+	rows, err := db.DB.Query("SELECT " + savedSearchColumns + " FROM saved_searches WHERE NOT muted")
+	if err != nil {
+		return err
+	}
+	var searches []models.SavedSearch
+	oldest := now
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		searches = append(searches, search)
+		if search.LastMatchedAt.Before(oldest) {
+			oldest = search.LastMatchedAt
+		}
+	}
+	rows.Close()
+
+	if len(searches) > 0 {
+		jobRows, err := db.DB.Query(
+			"SELECT "+jobColumns+", published_at FROM jobs WHERE "+publishedJobCondition+" AND published_at > $1 AND published_at <= $2",
+			oldest, now,
+		)
+		if err != nil {
+			return err
+		}
+		var jobs []models.Job
+		publishedAt := make(map[string]time.Time)
+		for jobRows.Next() {
+			var published time.Time
+			job, err := scanJob(extraColumns{jobRows, []interface{}{&published}})
+			if err != nil {
+				jobRows.Close()
+				return err
+			}
+			jobs = append(jobs, job)
+			publishedAt[job.ID] = published
+		}
+		jobRows.Close()
+
+		userIDs := make([]string, 0, len(searches))
+		for _, search := range searches {
+			userIDs = append(userIDs, search.UserID)
+		}
+		studentAccess, err := usersWithStudentAccess(userIDs)
+		if err != nil {
+			return err
+		}
+
+		queued := 0
+		for _, search := range searches {
+			for _, job := range jobs {
+				if !publishedAt[job.ID].After(search.LastMatchedAt) || !jobVisibleTo(job, studentAccess[search.UserID]) ||
+					!savedSearchMatches(search, job) {
+					continue
+				}
+				result, err := db.DB.Exec(`
+					INSERT INTO alert_notifications (id, saved_search_id, user_id, job_id, deliver_at, created_at)
+					VALUES ($1, $2, $3, $4, $5, $6)
+					ON CONFLICT (saved_search_id, job_id) DO NOTHING
+				`, uuid.New().String(), search.ID, search.UserID, job.ID, alertDeliveryTime(search.Frequency, now), now)
+				if err != nil {
+					return err
+				}
+				if affected, _ := result.RowsAffected(); affected > 0 {
+					queued++
+				}
+			}
+		}
+		if queued > 0 {
+			log.Printf("✅ Queued %d job alert notifications", queued)
+		}
+	}
+
+	_, err = db.DB.Exec("UPDATE saved_searches SET last_matched_at = $1 WHERE last_matched_at < $1", now)
+	return err
+}
+
+// savedSearchMatches reports whether a job satisfies every criterion of a saved search
+func savedSearchMatches(search models.SavedSearch, job models.Job) bool {
+	if search.Category != "" && search.Category != "All" && search.Category != job.Category {
+		return false
+	}
+	if search.Type != "" && !strings.EqualFold(search.Type, job.Type) {
+		return false
+	}
+	if search.Location != "" && !strings.Contains(strings.ToLower(job.Location), strings.ToLower(search.Location)) {
+		return false
+	}
+
+	if search.Keywords != "" {
+		text := strings.ToLower(strings.Join(append([]string{
+			job.Title, job.Company, job.Description,
+		}, job.Requirements...), " "))
+		for _, keyword := range strings.Fields(strings.ToLower(search.Keywords)) {
+			if !strings.Contains(text, keyword) {
+				return false
+			}
+		}
+	}
+
+	if search.MinSalary > 0 {
+		amounts, unit := parseSalary(job.Salary)
+		if len(amounts) == 0 {
+			return false
+		}
+		top := amounts[len(amounts)-1] * annualSalaryFactor(unit)
+		if top < float64(search.MinSalary) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// annualSalaryFactor converts a pay period from parseSalary into a yearly multiplier
+func annualSalaryFactor(unit string) float64 {
+	switch unit {
+	case "HOUR":
+		return 2080
+	case "WEEK":
+		return 52
+	case "MONTH":
+		return 12
+	}
+	return 1
+}
+
+// alertDeliveryTime decides when a queued notification is due: immediately
+// for instant alerts, at the next UTC midnight for daily digests and the next
+// Monday for weekly ones
+func alertDeliveryTime(frequency string, now time.Time) time.Time {
+	midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	switch frequency {
+	case "daily":
+		return midnight
+	case "weekly":
+		daysUntilMonday := (8 - int(midnight.Weekday())) % 7
+		return midnight.AddDate(0, 0, daysUntilMonday)
+	}
+	return now
+}
+
+// deliverJobAlerts emails every notification whose deliver_at has passed, one
+// message per saved search, so daily and weekly alerts arrive as digests.
+// Notifications are only marked sent once their email went out; failed ones
+// are retried on the next run. Those of muted searches are dropped, like the
+// matches a muted search skips.
+func deliverJobAlerts(now time.Time) error {
+	rows, err := db.DB.Query(`
+		SELECT id, saved_search_id, job_id FROM alert_notifications
+		WHERE sent_at IS NULL AND deliver_at <= $1
+		ORDER BY created_at
+	`, now)
+	if err != nil {
+		return err
+	}
+	var order []string
+	notifications := map[string][]string{}
+	jobIDs := map[string][]string{}
+	for rows.Next() {
+		var id, searchID, jobID string
+		if err := rows.Scan(&id, &searchID, &jobID); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, seen := notifications[searchID]; !seen {
+			order = append(order, searchID)
+		}
+		notifications[searchID] = append(notifications[searchID], id)
+		jobIDs[searchID] = append(jobIDs[searchID], jobID)
+	}
+	rows.Close()
+
+	sent := 0
+	for _, searchID := range order {
+		delivered, err := deliverSavedSearchAlert(searchID, notifications[searchID], jobIDs[searchID], now)
+		if err != nil {
+			log.Printf("❌ Error delivering job alert %s: %v", searchID, err)
+			continue
+		}
+		if delivered {
+			sent++
+		}
+	}
+	if sent > 0 {
+		log.Printf("✅ Sent %d job alert emails", sent)
+	}
+	return nil
+}
+
+// deliverSavedSearchAlert emails one saved search's due notifications and
+// marks them sent. It reports whether an email went out: none is sent when
+// the search is muted or every matched job has since been taken down.
+func deliverSavedSearchAlert(searchID string, notificationIDs, jobIDs []string, now time.Time) (bool, error) {
+	search, err := scanSavedSearch(db.DB.QueryRow("SELECT "+savedSearchColumns+" FROM saved_searches WHERE id = $1", searchID))
+	if err != nil {
+		return false, err
+	}
+	if search.Muted {
+		_, err := db.DB.Exec("DELETE FROM alert_notifications WHERE id = ANY($1)", pq.Array(notificationIDs))
+		return false, err
+	}
+
+	var email string
+	if err := db.DB.QueryRow("SELECT email FROM profiles WHERE id = $1", search.UserID).Scan(&email); err != nil {
+		return false, err
+	}
+
+	rows, err := db.DB.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE id = ANY($1) AND "+publishedJobCondition+" ORDER BY posted_date DESC",
+		pq.Array(jobIDs),
+	)
+	if err != nil {
+		return false, err
+	}
+	var jobs []models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			rows.Close()
+			return false, err
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+
+	if len(jobs) > 0 {
+		if err := outbox.Send(jobAlertEmail(search, email, jobs)); err != nil {
+			return false, err
+		}
+	}
+	_, err = db.DB.Exec("UPDATE alert_notifications SET sent_at = $2 WHERE id = ANY($1)", pq.Array(notificationIDs), now)
+	return len(jobs) > 0, err
+}
+
+// jobAlertEmail lists the jobs that matched a saved search, with the link
+// that mutes it
+func jobAlertEmail(search models.SavedSearch, to string, jobs []models.Job) mailer.Message {
+	subject := fmt.Sprintf("New job matching \"%s\"", search.Name)
+	switch {
+	case search.Frequency == "daily":
+		subject = fmt.Sprintf("Your daily job alert: %d new for \"%s\"", len(jobs), search.Name)
+	case search.Frequency == "weekly":
+		subject = fmt.Sprintf("Your weekly job alert: %d new for \"%s\"", len(jobs), search.Name)
+	case len(jobs) > 1:
+		subject = fmt.Sprintf("%d new jobs matching \"%s\"", len(jobs), search.Name)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "New jobs on GatorHire match your saved search \"%s\":\n\n", search.Name)
+	for _, job := range jobs {
+		fmt.Fprintf(&body, "%s at %s (%s)\n%s\n\n", job.Title, job.Company, job.Location, jobURL(job.ID))
+	}
+	fmt.Fprintf(&body, "To stop receiving this alert, pause it here:\n%s\n\n", search.UnsubscribeURL)
+	body.WriteString("You can turn it back on or delete it from your job alerts on GatorHire.\n")
+
+	return mailer.Message{To: to, Subject: subject, Body: body.String()}
+}
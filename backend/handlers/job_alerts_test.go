@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gatorhire/backend/mailer"
+	"github.com/gatorhire/backend/models"
+)
+
+// ✅ Test matching jobs against saved search criteria
+func TestSavedSearchMatches(t *testing.T) {
+	job := models.Job{
+		Title:        "Backend Engineer",
+		Company:      "DataSystems",
+		Location:     "New York, NY",
+		Type:         "Full-time",
+		Salary:       "$130,000 - $160,000",
+		Category:     "Technology",
+		Requirements: []string{"Experience with Go or similar languages"},
+	}
+
+	assert.True(t, savedSearchMatches(models.SavedSearch{Keywords: "backend go", Location: "new york"}, job))
+	assert.True(t, savedSearchMatches(models.SavedSearch{Type: "full-time", MinSalary: 150000}, job))
+	assert.False(t, savedSearchMatches(models.SavedSearch{MinSalary: 170000}, job))
+	assert.False(t, savedSearchMatches(models.SavedSearch{Keywords: "rust"}, job))
+	assert.False(t, savedSearchMatches(models.SavedSearch{Category: "Healthcare"}, job))
+
+	hourly := models.Job{Salary: "$40/hour"}
+	assert.True(t, savedSearchMatches(models.SavedSearch{MinSalary: 80000}, hourly))
+}
+
+// ✅ Test digest delivery times
+func TestAlertDeliveryTime(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2025, 3, 5, 15, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, now, alertDeliveryTime("instant", now))
+	assert.Equal(t, time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC), alertDeliveryTime("daily", now))
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), alertDeliveryTime("weekly", now))
+}
+
+// ✅ Test alert emails list the matches and the link that pauses the alert
+func TestJobAlertEmail(t *testing.T) {
+	t.Setenv("PUBLIC_SITE_URL", "https://gatorhire.example")
+	search := models.SavedSearch{
+		Name:           "Backend roles",
+		Frequency:      "daily",
+		UnsubscribeURL: "https://api.gatorhire.example/alerts/unsubscribe?token=abc",
+	}
+	jobs := []models.Job{
+		{ID: "1", Title: "Backend Engineer", Company: "DataSystems", Location: "Remote"},
+		{ID: "2", Title: "Platform Engineer", Company: "Gator Labs", Location: "Gainesville, FL"},
+	}
+
+	dir := t.TempDir()
+	defer SetMailer(outbox)
+	SetMailer(mailer.File{Dir: dir, From: "no-reply@gatorhire.local"})
+	assert.Nil(t, outbox.Send(jobAlertEmail(search, "student@ufl.edu", jobs)))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-student@ufl.edu.eml"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+	raw, err := os.ReadFile(files[0])
+	assert.Nil(t, err)
+	email := string(raw)
+	assert.Contains(t, email, "Subject: Your daily job alert: 2 new for \"Backend roles\"")
+	assert.Contains(t, email, "Backend Engineer at DataSystems (Remote)\r\nhttps://gatorhire.example/jobs/1")
+	assert.Contains(t, email, "https://gatorhire.example/jobs/2")
+	assert.Contains(t, email, "pause it here:\r\nhttps://api.gatorhire.example/alerts/unsubscribe?token=abc")
+
+	search.Frequency = "instant"
+	assert.Equal(t, "New job matching \"Backend roles\"", jobAlertEmail(search, "student@ufl.edu", jobs[:1]).Subject)
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// defaultArchiveRetentionDays is how long archived jobs are kept before they
+// may be purged, unless JOB_ARCHIVE_RETENTION_DAYS says otherwise
+const defaultArchiveRetentionDays = 365
+
+// jobDependentTables lists every table that references jobs, in the order a
+// purge deletes from them. Applications go before the revisions they point
+// at; tables declared ON DELETE CASCADE are covered by the final delete.
+var jobDependentTables = []string{
+	"alert_notifications",
+	"job_daily_stats",
+	"job_view_viewers",
+	"job_dismissals",
+	"saved_jobs",
+	"applications",
+	"job_revisions",
+}
+
+// archiveRetention returns the configured retention period for archived jobs
+func archiveRetention() time.Duration {
+	days := defaultArchiveRetentionDays
+	if value, err := strconv.Atoi(os.Getenv("JOB_ARCHIVE_RETENTION_DAYS")); err == nil && value > 0 {
+		days = value
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DeleteJob archives a job (jobs:delete). The job disappears from listings,
+// search and feeds, but its applications, saved jobs, revisions and
+// analytics are kept, and it can be restored until it is purged.
+func DeleteJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	jobID := mux.Vars(r)["id"]
+	var archivedAt sql.NullTime
+	err = db.DB.QueryRow("SELECT archived_at FROM jobs WHERE id = $1", jobID).Scan(&archivedAt)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error fetching job to archive: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if archivedAt.Valid {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job is already archived"})
+		return
+	}
+
+	_, err = db.DB.Exec(
+		"UPDATE jobs SET archived_at = NOW(), archived_by = $2 WHERE id = $1 AND archived_at IS NULL",
+		jobID, userID,
+	)
+	if err != nil {
+		log.Printf("❌ Error archiving job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to delete job"})
+		return
+	}
+	jobRemoved(jobID)
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// RestoreJob brings an archived job back (jobs:delete)
+func RestoreJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["id"]
+	result, err := db.DB.Exec(
+		"UPDATE jobs SET archived_at = NULL, archived_by = NULL WHERE id = $1 AND archived_at IS NOT NULL",
+		jobID,
+	)
+	if err != nil {
+		log.Printf("❌ Error restoring job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No archived job with this ID"})
+		return
+	}
+
+	job, err := fetchJob(jobID)
+	if err != nil {
+		log.Printf("❌ Error fetching restored job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	jobChanged(job)
+
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetArchivedJobs lists archived jobs, most recently archived first (jobs:archive)
+func GetArchivedJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := db.DB.Query("SELECT " + jobColumns + " FROM jobs WHERE archived_at IS NOT NULL ORDER BY archived_at DESC")
+	if err != nil {
+		log.Printf("❌ Error querying archived jobs: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			log.Printf("❌ Error scanning archived job: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing job data"})
+			return
+		}
+		jobs = append(jobs, job)
+	}
+
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// purgeArchivedJobs permanently deletes jobs archived before cutoff together
+// with everything that references them, in one transaction
+func purgeArchivedJobs(cutoff time.Time, dryRun bool) (models.PurgeReport, error) {
+	report := models.PurgeReport{DryRun: dryRun, ArchivedUntil: cutoff.Format("2006-01-02"), JobIDs: []string{}}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM jobs WHERE archived_at < $1 FOR UPDATE", cutoff)
+	if err != nil {
+		return report, err
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return report, err
+		}
+		report.JobIDs = append(report.JobIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	report.Purged = len(report.JobIDs)
+	if dryRun || report.Purged == 0 {
+		return report, nil
+	}
+
+	ids := pq.Array(report.JobIDs)
+	for _, table := range jobDependentTables {
+		// table comes from jobDependentTables, never from user input
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE job_id = ANY($1)", ids); err != nil {
+			return report, err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM jobs WHERE id = ANY($1)", ids); err != nil {
+		return report, err
+	}
+
+	return report, tx.Commit()
+}
+
+// PurgeArchivedJobs permanently deletes jobs that have been archived for
+// longer than the retention period, including their applications, saved
+// jobs, revisions and analytics (jobs:archive). ?dryRun=true only lists them.
+func PurgeArchivedJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	report, err := purgeArchivedJobs(time.Now().Add(-archiveRetention()), dryRun)
+	if err != nil {
+		log.Printf("❌ Error purging archived jobs: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to purge archived jobs"})
+		return
+	}
+	if !dryRun {
+		log.Printf("🗑️ Purged %d archived jobs", report.Purged)
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test the retention period comes from the environment, with a default
+func TestArchiveRetention(t *testing.T) {
+	t.Setenv("JOB_ARCHIVE_RETENTION_DAYS", "")
+	assert.Equal(t, 365*24*time.Hour, archiveRetention())
+
+	t.Setenv("JOB_ARCHIVE_RETENTION_DAYS", "30")
+	assert.Equal(t, 30*24*time.Hour, archiveRetention())
+
+	t.Setenv("JOB_ARCHIVE_RETENTION_DAYS", "-5")
+	assert.Equal(t, 365*24*time.Hour, archiveRetention())
+}
+
+// ✅ Test archived jobs are never public
+func TestArchivedJobIsNotPublished(t *testing.T) {
+	job := models.Job{Status: "active", ModerationStatus: models.ModerationApproved}
+	assert.True(t, jobIsPublished(job))
+
+	now := time.Now()
+	job.ArchivedAt = &now
+	assert.False(t, jobIsPublished(job))
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/formschema"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+)
+
+// maxImportSize caps the size of an uploaded import file
+const maxImportSize = 10 << 20
+
+// csvListSeparator separates items of list columns (requirements, benefits, ...) in CSV imports
+const csvListSeparator = "|"
+
+var (
+	validJobCategories = map[string]bool{
+		"Technology":  true,
+		"Healthcare":  true,
+		"Education":   true,
+		"Business":    true,
+		"Creative":    true,
+		"Hospitality": true,
+	}
+
+	validJobStatuses = map[string]bool{
+		"active": true,
+		"closed": true,
+		"draft":  true,
+	}
+)
+
+// ImportJobs bulk-creates or updates jobs from a CSV or JSON upload (jobs:import).
+// The format comes from the "format" query parameter or the Content-Type
+// header, and "dryRun=true" validates every row without writing anything.
+func ImportJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	report, err := RunJobImport(http.MaxBytesReader(w, r.Body, maxImportSize), format, dryRun, userID)
+	if err != nil {
+		log.Printf("❌ Error importing jobs: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.Printf("✅ Job import finished: %d created, %d updated, %d failed (dry run: %t)",
+		report.Created, report.Updated, report.Failed, report.DryRun)
+	json.NewEncoder(w).Encode(report)
+}
+
+// RunJobImport parses jobs from reader in the given format ("csv" or "json")
+// and upserts each valid row by its externalId. Rows are independent: one bad
+// row is reported and skipped without affecting the others. It is shared by
+// the ImportJobs endpoint and the import-jobs command.
+func RunJobImport(reader io.Reader, format string, dryRun bool, importedBy string) (models.ImportReport, error) {
+	report := models.ImportReport{DryRun: dryRun, Rows: []models.ImportRowResult{}}
+
+	var jobs []models.Job
+	var err error
+	switch strings.ToLower(format) {
+	case "csv":
+		jobs, err = parseJobsCSV(reader)
+	case "json":
+		jobs, err = parseJobsJSON(reader)
+	default:
+		return report, fmt.Errorf("unsupported import format %q, expected csv or json", format)
+	}
+	if err != nil {
+		return report, err
+	}
+
+	seen := map[string]int{}
+	for i, job := range jobs {
+		result := models.ImportRowResult{Row: i + 1, ExternalID: job.ExternalID}
+		report.Total++
+
+		result.Errors = validateImportedJob(&job)
+		if previous, ok := seen[job.ExternalID]; ok && job.ExternalID != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("externalId duplicates row %d", previous))
+		}
+		seen[job.ExternalID] = result.Row
+
+		if len(result.Errors) == 0 {
+			result.JobID, result.Action, err = upsertImportedJob(job, dryRun, importedBy)
+			if err != nil {
+				log.Printf("❌ Error importing row %d: %v", result.Row, err)
+				result.Errors = append(result.Errors, "database error: "+err.Error())
+			}
+		}
+
+		switch {
+		case len(result.Errors) > 0:
+			result.Action = "error"
+			report.Failed++
+		case result.Action == "create":
+			report.Created++
+		default:
+			report.Updated++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	return report, nil
+}
+
+// importFormatFromContentType picks an import format from a Content-Type header
+func importFormatFromContentType(contentType string) string {
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return "csv"
+	case strings.Contains(contentType, "json"):
+		return "json"
+	}
+	return ""
+}
+
+// parseJobsJSON reads a JSON array of models.Job
+func parseJobsJSON(reader io.Reader) ([]models.Job, error) {
+	var jobs []models.Job
+	if err := json.NewDecoder(reader).Decode(&jobs); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return jobs, nil
+}
+
+// parseJobsCSV reads a CSV file whose header row uses the models.Job JSON
+// field names. Company details go in companyName, companyDescription,
+// companyWebsite, companyIndustry and companySize columns, and list columns
+// separate their items with "|".
+func parseJobsCSV(reader io.Reader) ([]models.Job, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	header := map[string]int{}
+	for i, name := range records[0] {
+		header[strings.TrimSpace(name)] = i
+	}
+	get := func(record []string, name string) string {
+		if i, ok := header[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	list := func(record []string, name string) []string {
+		var items []string
+		for _, item := range strings.Split(get(record, name), csvListSeparator) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+
+	var jobs []models.Job
+	for _, record := range records[1:] {
+		job := models.Job{
+			ExternalID:       get(record, "externalId"),
+			Title:            get(record, "title"),
+			Company:          get(record, "company"),
+			Location:         get(record, "location"),
+			Type:             get(record, "type"),
+			Salary:           get(record, "salary"),
+			Description:      get(record, "description"),
+			Requirements:     list(record, "requirements"),
+			Responsibilities: list(record, "responsibilities"),
+			Benefits:         list(record, "benefits"),
+			Category:         get(record, "category"),
+			Status:           get(record, "status"),
+			WorkArrangement:  get(record, "workArrangement"),
+			RemoteRegions:    list(record, "remoteRegions"),
+			RemoteTimeZones:  list(record, "remoteTimeZones"),
+		}
+
+		info := models.CompanyInfo{
+			Name:        get(record, "companyName"),
+			Description: get(record, "companyDescription"),
+			Website:     get(record, "companyWebsite"),
+			Industry:    get(record, "companyIndustry"),
+			Size:        get(record, "companySize"),
+		}
+		if info != (models.CompanyInfo{}) {
+			job.CompanyInfo = &info
+		}
+
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// validateImportedJob checks an imported job, filling in defaults, and
+// returns every problem found rather than stopping at the first one
+func validateImportedJob(job *models.Job) []string {
+	var problems []string
+	if strings.TrimSpace(job.ExternalID) == "" {
+		problems = append(problems, "externalId is required")
+	}
+	return append(problems, validateJob(job)...)
+}
+
+// validateJob checks the fields every posting needs and fills in defaults
+// for status and work arrangement
+func validateJob(job *models.Job) []string {
+	var problems []string
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"title", job.Title},
+		{"company", job.Company},
+		{"location", job.Location},
+		{"type", job.Type},
+		{"description", job.Description},
+		{"category", job.Category},
+	}
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			problems = append(problems, field.name+" is required")
+		}
+	}
+
+	if len(job.Requirements) == 0 {
+		problems = append(problems, "at least one requirement is required")
+	}
+	if job.Category != "" && !validJobCategories[job.Category] {
+		problems = append(problems, fmt.Sprintf("unknown category %q", job.Category))
+	}
+
+	if job.Status == "" {
+		job.Status = "active"
+	} else if !validJobStatuses[job.Status] {
+		problems = append(problems, fmt.Sprintf("invalid status %q", job.Status))
+	}
+
+	if err := applyWorkArrangementDefaults(job); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(job.ApplicationForm) > 0 && string(job.ApplicationForm) != "null" {
+		if _, err := formschema.Parse(job.ApplicationForm); err != nil {
+			problems = append(problems, "applicationForm: "+err.Error())
+		}
+	}
+
+	// Keep CompanyInfo in step with the posting's company name
+	if job.CompanyInfo != nil && job.CompanyInfo.Name == "" {
+		job.CompanyInfo.Name = job.Company
+	}
+
+	return problems
+}
+
+// upsertImportedJob creates the job, or updates the one with the same
+// externalId, and records a revision. In a dry run it only reports which of
+// the two would happen.
+func upsertImportedJob(job models.Job, dryRun bool, importedBy string) (string, string, error) {
+	var existingID string
+	err := db.DB.QueryRow("SELECT id FROM jobs WHERE external_id = $1", job.ExternalID).Scan(&existingID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", "", err
+	}
+
+	action := "create"
+	if existingID != "" {
+		action = "update"
+	}
+	if dryRun {
+		return existingID, action, nil
+	}
+
+	companyID, err := resolveCompanyID(job.CompanyID, job.Company)
+	if err != nil {
+		return "", "", err
+	}
+	job.CompanyID, _ = companyID.(string)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	if action == "create" {
+		job.ID = uuid.New().String()
+		job.PostedDate = time.Now()
+		job.CreatedBy = importedBy
+		err = insertJob(tx, job)
+	} else {
+		job.ID = existingID
+		_, err = tx.Exec(`
+			UPDATE jobs SET
+				title = $2, company = $3, location = $4, type = $5, salary = $6, description = $7,
+				requirements = $8, responsibilities = $9, benefits = $10, category = $11, status = $12,
+				company_info = $13, work_arrangement = $14, remote_regions = $15, remote_time_zones = $16,
+				company_id = NULLIF($17, ''), screening = $18, application_form = $19, students_only = $20
+			WHERE id = $1
+		`, job.ID, job.Title, job.Company, job.Location, job.Type, job.Salary, job.Description,
+			jsonList(job.Requirements), jsonList(job.Responsibilities), jsonList(job.Benefits), job.Category, job.Status,
+			jsonValue(job.CompanyInfo), job.WorkArrangement, jsonList(job.RemoteRegions), jsonList(job.RemoteTimeZones),
+			job.CompanyID, jsonValue(job.Screening), jsonValue(job.ApplicationForm), job.StudentsOnly)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	// Snapshot what is now stored, including the columns the update kept
+	stored, err := scanJob(tx.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = $1", job.ID))
+	if err != nil {
+		return "", "", err
+	}
+	if err := syncJobSkills(tx, stored); err != nil {
+		return "", "", err
+	}
+
+	// Re-importing an unchanged row must not add noise to the revision history
+	latest, err := fetchJobRevision(tx, job.ID, 0)
+	if err != nil || len(diffJobSnapshots(latest.Snapshot, stored)) > 0 {
+		if _, err := recordJobRevision(tx, stored, importedBy); err != nil {
+			return "", "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", err
+	}
+	jobChanged(stored)
+	return job.ID, action, nil
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test parsing a CSV import, including list and company columns
+func TestParseJobsCSV(t *testing.T) {
+	input := `externalId,title,company,location,type,description,category,requirements,companyName,companyWebsite
+ACME-1,Data Intern,Acme,"Remote (US)",Internship,Crunch numbers,Technology,Python | SQL,Acme Corp,https://acme.example
+ACME-2,,Acme,Gainesville,Part-time,Help out,Knitting,,,
+`
+	jobs, err := parseJobsCSV(strings.NewReader(input))
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 2)
+
+	assert.Equal(t, "ACME-1", jobs[0].ExternalID)
+	assert.Equal(t, []string{"Python", "SQL"}, jobs[0].Requirements)
+	assert.Equal(t, "Acme Corp", jobs[0].CompanyInfo.Name)
+	assert.Equal(t, "https://acme.example", jobs[0].CompanyInfo.Website)
+	assert.Nil(t, jobs[1].CompanyInfo)
+
+	assert.Empty(t, validateImportedJob(&jobs[0]))
+	assert.Equal(t, "active", jobs[0].Status)
+	assert.Equal(t, "remote", jobs[0].WorkArrangement)
+
+	problems := validateImportedJob(&jobs[1])
+	assert.Contains(t, problems, "title is required")
+	assert.Contains(t, problems, "at least one requirement is required")
+	assert.Contains(t, problems, `unknown category "Knitting"`)
+}
+
+// ✅ Test unsupported formats are rejected before touching the database
+func TestRunJobImportUnsupportedFormat(t *testing.T) {
+	_, err := RunJobImport(strings.NewReader(""), "xlsx", true, "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/lib/pq"
+)
+
+// jobColumns is the column list scanJob expects, in order. A job linked to a
+// company profile takes its company name and info from the profile, so the
+// copies stored on the job only matter for unlinked jobs.
+const jobColumns = `id, title,
+	COALESCE((SELECT c.name FROM companies c WHERE c.id = jobs.company_id), company),
+	location, type, salary, description,
+	requirements, responsibilities, benefits, posted_date, category, status,
+	COALESCE((SELECT jsonb_build_object(
+		'name', c.name, 'description', c.description, 'website', COALESCE(c.website, ''),
+		'industry', COALESCE(c.industry, ''), 'size', COALESCE(c.size, ''))
+		FROM companies c WHERE c.id = jobs.company_id), company_info),
+	created_by, work_arrangement, remote_regions, remote_time_zones,
+	external_id, company_id, moderation_status, archived_at, screening, application_form, students_only`
+
+// publishedJobCondition selects the jobs visible to the public: listings,
+// feeds, facets and alerts all filter on it. Submitted postings only count
+// once a moderator approved them, and deleted (archived) jobs never do.
+const publishedJobCondition = "status = 'active' AND moderation_status = 'approved' AND archived_at IS NULL"
+
+// jobIsPublished is the in-memory counterpart of publishedJobCondition
+func jobIsPublished(job models.Job) bool {
+	return job.Status == "active" && job.ModerationStatus == models.ModerationApproved && job.ArchivedAt == nil
+}
+
+// visibleJobCondition narrows publishedJobCondition to what a viewer may
+// see: jobs restricted to verified students are hidden from everyone else
+func visibleJobCondition(studentAccess bool) string {
+	if studentAccess {
+		return publishedJobCondition
+	}
+	return publishedJobCondition + " AND NOT students_only"
+}
+
+// jobVisibleTo is the in-memory counterpart of visibleJobCondition
+func jobVisibleTo(job models.Job, studentAccess bool) bool {
+	return jobIsPublished(job) && (studentAccess || !job.StudentsOnly)
+}
+
+// queryExecer is implemented by both *sql.DB and *sql.Tx, so helpers that
+// write can take part in a caller's transaction
+type queryExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// isUniqueViolation reports whether a write failed on a unique constraint
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraColumns scans columns selected after jobColumns alongside scanJob
+type extraColumns struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.extra...)...)
+}
+
+// scanJob reads a jobs row selected with jobColumns into a models.Job
+func scanJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var requirements, responsibilities, benefits, companyInfo, remoteRegions, remoteTimeZones, screening, applicationForm []byte
+	var createdBy, externalID, companyID sql.NullString
+	var archivedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID, &job.Title, &job.Company, &job.Location, &job.Type, &job.Salary, &job.Description,
+		&requirements, &responsibilities, &benefits, &job.PostedDate, &job.Category, &job.Status,
+		&companyInfo, &createdBy, &job.WorkArrangement, &remoteRegions, &remoteTimeZones,
+		&externalID, &companyID, &job.ModerationStatus, &archivedAt, &screening, &applicationForm, &job.StudentsOnly,
+	)
+	if err != nil {
+		return job, err
+	}
+
+	job.CreatedBy = createdBy.String
+	job.ExternalID = externalID.String
+	job.CompanyID = companyID.String
+	if archivedAt.Valid {
+		job.ArchivedAt = &archivedAt.Time
+	}
+	for _, field := range []struct {
+		raw  []byte
+		dest interface{}
+	}{
+		{requirements, &job.Requirements},
+		{responsibilities, &job.Responsibilities},
+		{benefits, &job.Benefits},
+		{remoteRegions, &job.RemoteRegions},
+		{remoteTimeZones, &job.RemoteTimeZones},
+	} {
+		if len(field.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(field.raw, field.dest); err != nil {
+			return job, err
+		}
+	}
+
+	if len(screening) > 0 && string(screening) != "null" {
+		job.Screening = &models.ScreeningSettings{}
+		if err := json.Unmarshal(screening, job.Screening); err != nil {
+			return job, err
+		}
+	}
+
+	if len(applicationForm) > 0 && string(applicationForm) != "null" {
+		job.ApplicationForm = json.RawMessage(applicationForm)
+	}
+
+	if len(companyInfo) > 0 && string(companyInfo) != "null" {
+		job.CompanyInfo = &models.CompanyInfo{}
+		if err := json.Unmarshal(companyInfo, job.CompanyInfo); err != nil {
+			return job, err
+		}
+	}
+
+	return job, nil
+}
+
+// fetchJob loads a single job by ID. It returns sql.ErrNoRows when the job
+// does not exist.
+func fetchJob(id string) (models.Job, error) {
+	return scanJob(db.DB.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = $1", id))
+}
+
+// insertJob writes a new job. An empty ModerationStatus means approved.
+func insertJob(exec queryExecer, job models.Job) error {
+	if job.ModerationStatus == "" {
+		job.ModerationStatus = models.ModerationApproved
+	}
+	_, err := exec.Exec(`
+		INSERT INTO jobs (
+			id, title, company, location, type, salary, description,
+			requirements, responsibilities, benefits, posted_date, category, status,
+			company_info, created_by, work_arrangement, remote_regions, remote_time_zones,
+			external_id, company_id, moderation_status, screening, application_form, students_only
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NULLIF($15, ''), $16, $17, $18,
+			NULLIF($19, ''), NULLIF($20, ''), $21, $22, $23, $24)
+	`, job.ID, job.Title, job.Company, job.Location, job.Type, job.Salary, job.Description,
+		jsonList(job.Requirements), jsonList(job.Responsibilities), jsonList(job.Benefits), job.PostedDate, job.Category, job.Status,
+		jsonValue(job.CompanyInfo), job.CreatedBy, job.WorkArrangement, jsonList(job.RemoteRegions), jsonList(job.RemoteTimeZones),
+		job.ExternalID, job.CompanyID, job.ModerationStatus, jsonValue(job.Screening), jsonValue(job.ApplicationForm), job.StudentsOnly)
+	return err
+}
+
+// jsonValue encodes a value for a JSONB column, using SQL NULL for nil
+func jsonValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return string(data)
+}
+
+// jsonList encodes a list for a JSONB column, using SQL NULL for an empty list
+func jsonList(items []string) interface{} {
+	if len(items) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(items)
+	return string(data)
+}
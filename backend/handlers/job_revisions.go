@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// recordJobRevision stores an immutable snapshot of job as its next revision.
+// UpdateJob should call it with the same *sql.Tx it uses for the update so
+// the job and its history can never disagree. The job row is locked until
+// the transaction ends, so concurrent writers number their revisions in turn.
+func recordJobRevision(tx *sql.Tx, job models.Job, changedBy string) (models.JobRevision, error) {
+	revision := models.JobRevision{
+		ID:        uuid.New().String(),
+		JobID:     job.ID,
+		Snapshot:  job,
+		ChangedBy: changedBy,
+		CreatedAt: time.Now(),
+	}
+
+	snapshot, err := json.Marshal(job)
+	if err != nil {
+		return revision, err
+	}
+
+	var locked string
+	if err := tx.QueryRow("SELECT id FROM jobs WHERE id = $1 FOR UPDATE", job.ID).Scan(&locked); err != nil {
+		return revision, err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO job_revisions (id, job_id, revision, snapshot, changed_by, created_at)
+		SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, $3, NULLIF($4, ''), $5
+		FROM job_revisions WHERE job_id = $2
+		RETURNING revision
+	`, revision.ID, job.ID, string(snapshot), changedBy, revision.CreatedAt).Scan(&revision.Revision)
+
+	return revision, err
+}
+
+// scanJobRevision reads an id, job_id, revision, snapshot, changed_by, created_at row
+func scanJobRevision(row rowScanner) (models.JobRevision, error) {
+	var revision models.JobRevision
+	var snapshot []byte
+	var changedBy sql.NullString
+
+	err := row.Scan(&revision.ID, &revision.JobID, &revision.Revision, &snapshot, &changedBy, &revision.CreatedAt)
+	if err != nil {
+		return revision, err
+	}
+
+	revision.ChangedBy = changedBy.String
+	err = json.Unmarshal(snapshot, &revision.Snapshot)
+	return revision, err
+}
+
+// fetchJobRevision loads one revision of a job. A revision number of 0 means
+// the latest one.
+func fetchJobRevision(exec queryExecer, jobID string, number int) (models.JobRevision, error) {
+	if number == 0 {
+		return scanJobRevision(exec.QueryRow(`
+			SELECT id, job_id, revision, snapshot, changed_by, created_at
+			FROM job_revisions WHERE job_id = $1
+			ORDER BY revision DESC LIMIT 1
+		`, jobID))
+	}
+	return scanJobRevision(exec.QueryRow(`
+		SELECT id, job_id, revision, snapshot, changed_by, created_at
+		FROM job_revisions WHERE job_id = $1 AND revision = $2
+	`, jobID, number))
+}
+
+// liveJobRevision returns the ID of the revision matching the job as it is
+// stored right now. If the job has no history yet, or was changed without a
+// revision being recorded, a new revision is taken first so applications
+// always point at exactly what the candidate saw.
+func liveJobRevision(jobID string) (string, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Lock the job first, so concurrent applicants agree on one revision
+	job, err := scanJob(tx.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = $1 FOR UPDATE", jobID))
+	if err != nil {
+		return "", err
+	}
+
+	latest, err := fetchJobRevision(tx, jobID, 0)
+	if err == nil && len(diffJobSnapshots(latest.Snapshot, job)) == 0 {
+		return latest.ID, nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	revision, err := recordJobRevision(tx, job, "")
+	if err != nil {
+		return "", err
+	}
+	return revision.ID, tx.Commit()
+}
+
+// diffJobSnapshots compares two job snapshots field by field using their JSON
+// names, returning the changed fields in alphabetical order
+func diffJobSnapshots(from, to models.Job) []models.JobFieldChange {
+	fromFields := jobFieldMap(from)
+	toFields := jobFieldMap(to)
+
+	names := make([]string, 0, len(fromFields)+len(toFields))
+	for name := range fromFields {
+		names = append(names, name)
+	}
+	for name := range toFields {
+		if _, ok := fromFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.JobFieldChange{}
+	for _, name := range names {
+		if !reflect.DeepEqual(fromFields[name], toFields[name]) {
+			changes = append(changes, models.JobFieldChange{
+				Field: name,
+				From:  fromFields[name],
+				To:    toFields[name],
+			})
+		}
+	}
+	return changes
+}
+
+// jobFieldMap flattens a job into its JSON representation so snapshots taken
+// before and after a round trip through the database compare equal
+func jobFieldMap(job models.Job) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// GetJobRevisions returns the full revision history of a job, newest first (jobs:read)
+func GetJobRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["id"]
+
+	rows, err := db.DB.Query(`
+		SELECT id, job_id, revision, snapshot, changed_by, created_at
+		FROM job_revisions
+		WHERE job_id = $1
+		ORDER BY revision DESC
+	`, jobID)
+	if err != nil {
+		log.Printf("❌ Error querying job revisions: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	revisions := []models.JobRevision{}
+	for rows.Next() {
+		revision, err := scanJobRevision(rows)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing revision data"})
+			return
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if len(revisions) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// GetJobRevisionDiff returns the field-level changes between two revisions of
+// a job (jobs:read). "to" defaults to the latest revision and "from" to the
+// one before it.
+func GetJobRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	jobID := mux.Vars(r)["id"]
+	params := r.URL.Query()
+
+	toNumber, err := optionalRevisionNumber(params.Get("to"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid revision number"})
+		return
+	}
+	to, err := fetchJobRevision(db.DB, jobID, toNumber)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Revision not found"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error fetching job revision: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	fromNumber, err := optionalRevisionNumber(params.Get("from"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid revision number"})
+		return
+	}
+	if fromNumber == 0 {
+		fromNumber = to.Revision - 1
+	}
+
+	// Diffing the first revision against "nothing" lists every field
+	var from models.JobRevision
+	if fromNumber > 0 {
+		from, err = fetchJobRevision(db.DB, jobID, fromNumber)
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Revision not found"})
+			return
+		} else if err != nil {
+			log.Printf("❌ Error fetching job revision: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobId":   jobID,
+		"from":    from.Revision,
+		"to":      to.Revision,
+		"changes": diffJobSnapshots(from.Snapshot, to.Snapshot),
+	})
+}
+
+// optionalRevisionNumber parses a revision query parameter, returning 0 when it is absent
+func optionalRevisionNumber(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err == nil && number < 1 {
+		err = strconv.ErrRange
+	}
+	return number, err
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gatorhire/backend/models"
+)
+
+// ✅ Test field-level diffs between job snapshots
+func TestDiffJobSnapshots(t *testing.T) {
+	before := models.Job{
+		ID:           "1",
+		Title:        "Backend Engineer",
+		Salary:       "$130,000 - $160,000",
+		Requirements: []string{"Go", "SQL"},
+	}
+	after := before
+	after.Salary = "$110,000 - $140,000"
+	after.Requirements = []string{"Go", "SQL", "Kubernetes"}
+
+	changes := diffJobSnapshots(before, after)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "requirements", changes[0].Field)
+	assert.Equal(t, "salary", changes[1].Field)
+	assert.Equal(t, "$130,000 - $160,000", changes[1].From)
+	assert.Equal(t, "$110,000 - $140,000", changes[1].To)
+
+	assert.Empty(t, diffJobSnapshots(before, before))
+}
+
+// ✅ Test parsing of optional revision numbers
+func TestOptionalRevisionNumber(t *testing.T) {
+	number, err := optionalRevisionNumber("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, number)
+
+	number, err = optionalRevisionNumber("3")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, number)
+
+	_, err = optionalRevisionNumber("0")
+	assert.NotNil(t, err)
+	_, err = optionalRevisionNumber("abc")
+	assert.NotNil(t, err)
+}
+
+// snapshotRow stands in for a job_revisions row in scanJobRevision
+type snapshotRow string
+
+func (s snapshotRow) Scan(dest ...interface{}) error {
+	*dest[0].(*string), *dest[1].(*string), *dest[2].(*int) = "rev-1", "1", 1
+	*dest[3].(*[]byte) = []byte(s)
+	*dest[5].(*time.Time) = time.Now()
+	return nil
+}
+
+// ✅ Test snapshots backfilled by the migration decode like recorded ones
+func TestScanBackfilledJobRevision(t *testing.T) {
+	revision, err := scanJobRevision(snapshotRow(`{"id": "1", "title": "Analyst", "postedDate": "2025-03-04T09:30:00.250000Z"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2025, 3, 4, 9, 30, 0, 250000000, time.UTC), revision.Snapshot.PostedDate)
+
+	_, err = scanJobRevision(snapshotRow(`{"id": "1", "postedDate": "2025-03-04T09:30:00.25"}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// templateVariablePattern matches {{name}} and {{ name }}
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}`)
+
+// templateColumns is the column list scanJobTemplate expects, in order
+const templateColumns = `id, company_id, name, title, location, type, salary, category, description,
+	requirements, responsibilities, benefits, COALESCE(work_arrangement, ''), screening,
+	COALESCE(created_by, ''), created_at, updated_at`
+
+// scanJobTemplate reads a job_templates row selected with templateColumns
+func scanJobTemplate(row rowScanner) (models.JobTemplate, error) {
+	var template models.JobTemplate
+	var requirements, responsibilities, benefits, screening []byte
+
+	err := row.Scan(
+		&template.ID, &template.CompanyID, &template.Name, &template.Title, &template.Location,
+		&template.Type, &template.Salary, &template.Category, &template.Description,
+		&requirements, &responsibilities, &benefits, &template.WorkArrangement, &screening,
+		&template.CreatedBy, &template.CreatedAt, &template.UpdatedAt,
+	)
+	if err != nil {
+		return template, err
+	}
+
+	for _, field := range []struct {
+		raw  []byte
+		dest interface{}
+	}{
+		{requirements, &template.Requirements},
+		{responsibilities, &template.Responsibilities},
+		{benefits, &template.Benefits},
+		{screening, &template.Screening},
+	} {
+		if len(field.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(field.raw, field.dest); err != nil {
+			return template, err
+		}
+	}
+	return template, nil
+}
+
+// upcomingSemester names the next semester to hire for: Summer while the
+// spring term runs, Fall during the summer, and next Spring during the fall
+func upcomingSemester(now time.Time) string {
+	year := now.Year()
+	switch {
+	case now.Month() <= time.April:
+		return fmt.Sprintf("Summer %d", year)
+	case now.Month() <= time.July:
+		return fmt.Sprintf("Fall %d", year)
+	default:
+		return fmt.Sprintf("Spring %d", year+1)
+	}
+}
+
+// templateVariables returns the built-in variables ({{semester}}, {{year}},
+// {{company}}) overridden by the ones supplied by the user
+func templateVariables(now time.Time, company string, supplied map[string]string) map[string]string {
+	variables := map[string]string{
+		"semester": upcomingSemester(now),
+		"year":     strconv.Itoa(now.Year()),
+		"company":  company,
+	}
+	for name, value := range supplied {
+		variables[name] = value
+	}
+	return variables
+}
+
+// renderTemplateText replaces {{variables}} in text and records the names
+// that have no value in missing
+func renderTemplateText(text string, variables map[string]string, missing map[string]bool) string {
+	return templateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := templateVariablePattern.FindStringSubmatch(match)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		missing[name] = true
+		return match
+	})
+}
+
+// instantiateTemplate builds a job from a template. It fails with the list of
+// variables that were left unresolved.
+func instantiateTemplate(template models.JobTemplate, variables map[string]string) (models.Job, error) {
+	missing := map[string]bool{}
+	render := func(text string) string { return renderTemplateText(text, variables, missing) }
+	renderList := func(items []string) []string {
+		var rendered []string
+		for _, item := range items {
+			rendered = append(rendered, render(item))
+		}
+		return rendered
+	}
+
+	job := models.Job{
+		Title:            render(template.Title),
+		Location:         render(template.Location),
+		Type:             template.Type,
+		Salary:           render(template.Salary),
+		Category:         template.Category,
+		Description:      render(template.Description),
+		Requirements:     renderList(template.Requirements),
+		Responsibilities: renderList(template.Responsibilities),
+		Benefits:         renderList(template.Benefits),
+		WorkArrangement:  template.WorkArrangement,
+		Screening:        template.Screening,
+		CompanyID:        template.CompanyID,
+	}
+
+	if len(missing) > 0 {
+		var names []string
+		for name := range missing {
+			names = append(names, "{{"+name+"}}")
+		}
+		sort.Strings(names)
+		return job, fmt.Errorf("missing values for %s", strings.Join(names, ", "))
+	}
+	return job, nil
+}
+
+// screeningProblem reports what an application is missing under a job's
+// screening settings, or "" when it is complete
+func screeningProblem(screening *models.ScreeningSettings, app models.Application) string {
+	if screening == nil {
+		return ""
+	}
+	switch {
+	case screening.RequireResume && strings.TrimSpace(app.ResumeURL) == "":
+		return "This job requires a resume"
+	case screening.RequireCoverLetter && strings.TrimSpace(app.CoverLetter) == "":
+		return "This job requires a cover letter"
+	case screening.RequirePortfolio && strings.TrimSpace(app.Portfolio) == "":
+		return "This job requires a portfolio link"
+	}
+	return ""
+}
+
+// validateJobTemplate trims and checks a template submitted by a user
+func validateJobTemplate(template *models.JobTemplate) string {
+	template.Name = strings.TrimSpace(template.Name)
+	template.Title = strings.TrimSpace(template.Title)
+	if template.Name == "" || template.Title == "" {
+		return "Template name and title are required"
+	}
+	if len(template.Requirements) == 0 {
+		return "At least one requirement is required"
+	}
+	if template.Category != "" && !validJobCategories[template.Category] {
+		return fmt.Sprintf("Unknown category %q", template.Category)
+	}
+	if template.WorkArrangement != "" {
+		template.WorkArrangement = normalizeWorkArrangement(template.WorkArrangement)
+		if template.WorkArrangement == "" {
+			return "Invalid work arrangement"
+		}
+	}
+	return ""
+}
+
+// createDraftJob saves a job as a draft owned by the user. Drafts by anyone
+// but moderators still go through moderation once they are submitted.
+func createDraftJob(job models.Job, userID, role string) (models.Job, error) {
+	job.ID = uuid.New().String()
+	job.PostedDate = time.Now()
+	job.Status = "draft"
+	job.CreatedBy = userID
+	job.ExternalID = ""
+	job.ArchivedAt = nil
+	job.ModerationStatus = models.ModerationApproved
+	if !rbac.RoleHas(role, rbac.ModerationReview) {
+		job.ModerationStatus = models.ModerationPending
+	}
+	if err := applyWorkArrangementDefaults(&job); err != nil {
+		return job, err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return job, err
+	}
+	defer tx.Rollback()
+
+	if err := insertJob(tx, job); err != nil {
+		return job, err
+	}
+	stored, err := scanJob(tx.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = $1", job.ID))
+	if err != nil {
+		return job, err
+	}
+	if _, err := recordJobRevision(tx, stored, userID); err != nil {
+		return job, err
+	}
+	if err := syncJobSkills(tx, stored); err != nil {
+		return job, err
+	}
+	if err := tx.Commit(); err != nil {
+		return job, err
+	}
+
+	jobChanged(stored)
+	return stored, nil
+}
+
+// CloneJob copies a job into a new draft (jobs:create for the job's company)
+func CloneJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	job, err := fetchJob(mux.Vars(r)["id"])
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error fetching job to clone: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !authorize(w, r, rbac.JobsCreate, rbac.Scope{CompanyID: job.CompanyID}) {
+		return
+	}
+	userID, role, _ := utils.GetUserFromToken(r)
+
+	draft, err := createDraftJob(job, userID, role)
+	if err != nil {
+		log.Printf("❌ Error cloning job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to clone job"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(draft)
+}
+
+// fetchAuthorizedTemplate loads a template and checks the user may manage it.
+// It writes the error response itself and returns false on failure.
+func fetchAuthorizedTemplate(w http.ResponseWriter, r *http.Request) (models.JobTemplate, bool) {
+	template, err := scanJobTemplate(db.DB.QueryRow(
+		"SELECT "+templateColumns+" FROM job_templates WHERE id = $1", mux.Vars(r)["id"],
+	))
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Template not found"})
+		return template, false
+	} else if err != nil {
+		log.Printf("❌ Error fetching job template: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return template, false
+	}
+	return template, authorize(w, r, rbac.JobsCreate, rbac.Scope{CompanyID: template.CompanyID})
+}
+
+// GetCompanyTemplates lists a company's job templates (jobs:create)
+func GetCompanyTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	companyID := mux.Vars(r)["id"]
+	if !authorize(w, r, rbac.JobsCreate, rbac.Scope{CompanyID: companyID}) {
+		return
+	}
+
+	rows, err := db.DB.Query("SELECT "+templateColumns+" FROM job_templates WHERE company_id = $1 ORDER BY name", companyID)
+	if err != nil {
+		log.Printf("❌ Error querying job templates: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	templates := []models.JobTemplate{}
+	for rows.Next() {
+		template, err := scanJobTemplate(rows)
+		if err != nil {
+			log.Printf("❌ Error scanning job template: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing template data"})
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	json.NewEncoder(w).Encode(templates)
+}
+
+// CreateCompanyTemplate adds a template to a company's library (jobs:create)
+func CreateCompanyTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	companyID := mux.Vars(r)["id"]
+	if !authorize(w, r, rbac.JobsCreate, rbac.Scope{CompanyID: companyID}) {
+		return
+	}
+	userID, _, _ := utils.GetUserFromToken(r)
+
+	var template models.JobTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if problem := validateJobTemplate(&template); problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	template.ID = uuid.New().String()
+	template.CompanyID = companyID
+	template.CreatedBy = userID
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+	_, err := db.DB.Exec(`
+		INSERT INTO job_templates (
+			id, company_id, name, title, location, type, salary, category, description,
+			requirements, responsibilities, benefits, work_arrangement, screening,
+			created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''), $14, $15, $16, $17)
+	`, template.ID, template.CompanyID, template.Name, template.Title, template.Location, template.Type,
+		template.Salary, template.Category, template.Description,
+		jsonList(template.Requirements), jsonList(template.Responsibilities), jsonList(template.Benefits),
+		template.WorkArrangement, jsonValue(template.Screening),
+		template.CreatedBy, template.CreatedAt, template.UpdatedAt)
+	if isUniqueViolation(err) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A template with this name already exists"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error creating job template: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create template"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// UpdateJobTemplate edits a template (jobs:create)
+func UpdateJobTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	existing, ok := fetchAuthorizedTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var template models.JobTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if problem := validateJobTemplate(&template); problem != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem})
+		return
+	}
+
+	template.ID = existing.ID
+	template.CompanyID = existing.CompanyID
+	template.CreatedBy = existing.CreatedBy
+	template.CreatedAt = existing.CreatedAt
+	template.UpdatedAt = time.Now()
+	_, err := db.DB.Exec(`
+		UPDATE job_templates SET
+			name = $2, title = $3, location = $4, type = $5, salary = $6, category = $7, description = $8,
+			requirements = $9, responsibilities = $10, benefits = $11, work_arrangement = NULLIF($12, ''),
+			screening = $13, updated_at = $14
+		WHERE id = $1
+	`, template.ID, template.Name, template.Title, template.Location, template.Type, template.Salary,
+		template.Category, template.Description,
+		jsonList(template.Requirements), jsonList(template.Responsibilities), jsonList(template.Benefits),
+		template.WorkArrangement, jsonValue(template.Screening), template.UpdatedAt)
+	if isUniqueViolation(err) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A template with this name already exists"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error updating job template: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update template"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteJobTemplate removes a template; jobs created from it are unaffected
+func DeleteJobTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	template, ok := fetchAuthorizedTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := db.DB.Exec("DELETE FROM job_templates WHERE id = $1", template.ID); err != nil {
+		log.Printf("❌ Error deleting job template: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// InstantiateJobTemplate creates a draft job from a template, filling in its
+// variables. The body may supply {"variables": {"semester": "Fall 2026"}};
+// {{semester}}, {{year}} and {{company}} have defaults.
+func InstantiateJobTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	template, ok := fetchAuthorizedTemplate(w, r)
+	if !ok {
+		return
+	}
+	userID, role, _ := utils.GetUserFromToken(r)
+
+	var body struct {
+		Variables map[string]string `json:"variables"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+	}
+
+	var company string
+	if err := db.DB.QueryRow("SELECT name FROM companies WHERE id = $1", template.CompanyID).Scan(&company); err != nil {
+		log.Printf("❌ Error fetching template company: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	job, err := instantiateTemplate(template, templateVariables(time.Now(), company, body.Variables))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	job.Company = company
+
+	draft, err := createDraftJob(job, userID, role)
+	if err != nil {
+		log.Printf("❌ Error creating job from template: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create job from template"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(draft)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test the default semester is the next one to hire for
+func TestUpcomingSemester(t *testing.T) {
+	assert.Equal(t, "Summer 2026", upcomingSemester(time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "Fall 2026", upcomingSemester(time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "Spring 2027", upcomingSemester(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+// ✅ Test variables are resolved across all text fields, user values winning
+func TestInstantiateTemplate(t *testing.T) {
+	template := models.JobTemplate{
+		CompanyID:    "acme",
+		Title:        "{{ semester }} Software Intern",
+		Description:  "Join {{company}} for {{semester}}.",
+		Requirements: []string{"Graduating in {{gradYear}} or later"},
+		Screening:    &models.ScreeningSettings{RequireResume: true},
+	}
+	now := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	job, err := instantiateTemplate(template, templateVariables(now, "Acme", map[string]string{"gradYear": "2028"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "Spring 2027 Software Intern", job.Title)
+	assert.Equal(t, "Join Acme for Spring 2027.", job.Description)
+	assert.Equal(t, []string{"Graduating in 2028 or later"}, job.Requirements)
+	assert.True(t, job.Screening.RequireResume)
+	assert.Equal(t, "acme", job.CompanyID)
+
+	job, err = instantiateTemplate(template, templateVariables(now, "Acme", map[string]string{"semester": "Fall 2027"}))
+	assert.EqualError(t, err, "missing values for {{gradYear}}")
+}
+
+// ✅ Test screening settings reject incomplete applications
+func TestScreeningProblem(t *testing.T) {
+	app := models.Application{ResumeURL: "https://example.com/cv.pdf"}
+	assert.Empty(t, screeningProblem(nil, app))
+	assert.Empty(t, screeningProblem(&models.ScreeningSettings{RequireResume: true}, app))
+	assert.Equal(t, "This job requires a cover letter",
+		screeningProblem(&models.ScreeningSettings{RequireResume: true, RequireCoverLetter: true}, app))
+}
+
+// ✅ Test only unique constraint failures count as name conflicts
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, isUniqueViolation(&pq.Error{Code: "23505"}))
+	assert.False(t, isUniqueViolation(&pq.Error{Code: "23503"}))
+	assert.False(t, isUniqueViolation(sql.ErrConnDone))
+	assert.False(t, isUniqueViolation(nil))
+}
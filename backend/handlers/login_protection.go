@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/mailer"
+	"github.com/gatorhire/backend/models"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// loginPolicy is how failed logins are throttled. The first freeFailures
+// failures cost nothing; each one after that makes the next attempt wait
+// twice as long as the last, up to maxBackoff, and lockoutAfter failures
+// lock logins out for lockout. Failures older than window are forgotten.
+type loginPolicy struct {
+	freeFailures int
+	maxBackoff   time.Duration
+	lockoutAfter int
+	lockout      time.Duration
+	window       time.Duration
+}
+
+// Accounts lock after ten failures. Addresses get far more room, since a
+// whole campus network can share one.
+var (
+	accountLoginPolicy = loginPolicy{freeFailures: 3, maxBackoff: 5 * time.Minute, lockoutAfter: 10, lockout: 15 * time.Minute, window: time.Hour}
+	ipLoginPolicy      = loginPolicy{freeFailures: 20, maxBackoff: 5 * time.Minute, lockoutAfter: 100, lockout: 30 * time.Minute, window: time.Hour}
+)
+
+// minFailedLoginTime is the least time a failed login takes, so unknown
+// emails (no password hash to check) answer as slowly as wrong passwords
+const minFailedLoginTime = 500 * time.Millisecond
+
+// invalidLoginError is the one answer to every failed login
+const invalidLoginError = "Invalid email or password"
+
+// wait returns how long to refuse logins after the given number of
+// consecutive failures, and whether that is a lockout
+func (p loginPolicy) wait(failures int) (time.Duration, bool) {
+	if failures >= p.lockoutAfter {
+		return p.lockout, true
+	}
+	if failures <= p.freeFailures {
+		return 0, false
+	}
+	backoff := time.Second
+	for i := p.freeFailures + 1; i < failures && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+	return backoff, false
+}
+
+// accountThrottleKey and ipThrottleKey name the login_throttles rows of an
+// email address, whether or not it has an account, and of a client address
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// clientIP returns the address a request came from. Behind a reverse
+// proxy, set TRUST_PROXY_HEADERS so the address the proxy saw is used.
+func clientIP(r *http.Request) string {
+	if trust, _ := strconv.ParseBool(os.Getenv("TRUST_PROXY_HEADERS")); trust {
+		forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if ip := strings.TrimSpace(forwarded[len(forwarded)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginLockedUntil returns when the later of the given throttles lifts, or
+// the zero time if none is in force
+func loginLockedUntil(keys ...string) (time.Time, error) {
+	var until sql.NullTime
+	err := db.DB.QueryRow(
+		"SELECT MAX(locked_until) FROM login_throttles WHERE key = ANY($1) AND locked_until > NOW()",
+		pq.Array(keys),
+	).Scan(&until)
+	return until.Time, err
+}
+
+// recordLoginFailure counts a failure against a throttle and applies its
+// policy. It reports whether this failure started a lockout, which is not
+// only the lockoutAfter-th failure: once a lockout lifts within the window,
+// each further failure locks logins again.
+func recordLoginFailure(key string, policy loginPolicy) (bool, error) {
+	var failures int
+	err := db.DB.QueryRow(`
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < NOW() - $2 * INTERVAL '1 second'
+				THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = NOW()
+		RETURNING failures
+	`, key, int(policy.window.Seconds())).Scan(&failures)
+	if err != nil {
+		return false, err
+	}
+
+	wait, lockout := policy.wait(failures)
+	if wait == 0 {
+		return false, nil
+	}
+	// The FROM clause reads the row as it was before this update
+	var started bool
+	err = db.DB.QueryRow(`
+		UPDATE login_throttles t SET locked_until = $2
+		FROM (SELECT locked_until FROM login_throttles WHERE key = $1) previous
+		WHERE t.key = $1
+		RETURNING previous.locked_until IS NULL OR previous.locked_until <= NOW()
+	`, key, time.Now().Add(wait)).Scan(&started)
+	return lockout && started, err
+}
+
+// notifyLockout tells the owner of an account, if there is one, that its
+// logins were locked
+func notifyLockout(email string) {
+	var fullName string
+	err := db.DB.QueryRow("SELECT full_name FROM profiles WHERE LOWER(email) = LOWER($1)", email).Scan(&fullName)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("❌ Error looking up locked account: %v", err)
+		}
+		return
+	}
+	sendEmail(mailer.Message{
+		To:      email,
+		Subject: "Your GatorHire account was locked",
+		Body: "Hi " + fullName + ",\n\n" +
+			"There were " + strconv.Itoa(accountLoginPolicy.lockoutAfter) + " or more failed attempts to log in to your GatorHire account, " +
+			"so logins are paused for " + strconv.Itoa(int(accountLoginPolicy.lockout.Minutes())) + " minutes.\n\n" +
+			"If this wasn't you, someone may be guessing your password: choose a new one with \"Forgot password\" at\n\n" +
+			siteURL() + "/login\n",
+	})
+}
+
+// bufferedResponse holds a response back so ProtectLogin can inspect it
+// before anything reaches the client
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(data)
+}
+
+// ProtectLogin guards a login handler against password guessing. Attempts
+// the handler rejects with a 401 are counted per email address and per
+// client address; repeated failures make the next attempt wait,
+// exponentially longer, and then lock logins out for a while, telling the
+// account owner. Every failure gets the same answer after the same minimum
+// time, so responses don't reveal whether an email has an account. Other
+// errors, such as a malformed request, pass through without being counted.
+func ProtectLogin(login http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		w.Header().Set("Content-Type", "application/json")
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		var credentials struct {
+			Email string `json:"email"`
+		}
+		if err != nil || json.Unmarshal(body, &credentials) != nil || strings.TrimSpace(credentials.Email) == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Email and password are required"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		accountKey, ipKey := accountThrottleKey(credentials.Email), ipThrottleKey(clientIP(r))
+		until, err := loginLockedUntil(accountKey, ipKey)
+		if err != nil {
+			log.Printf("❌ Error checking login throttle: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+			return
+		}
+		if !until.IsZero() {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Too many failed login attempts. Please try again later."})
+			return
+		}
+
+		response := &bufferedResponse{header: w.Header()}
+		login(response, r)
+		if response.status == 0 {
+			response.status = http.StatusOK
+		}
+
+		switch {
+		case response.status < 400:
+			if _, err := db.DB.Exec("DELETE FROM login_throttles WHERE key = $1", accountKey); err != nil {
+				log.Printf("⚠️ Error clearing login throttle: %v", err)
+			}
+			w.WriteHeader(response.status)
+			w.Write(response.body.Bytes())
+		case response.status != http.StatusUnauthorized:
+			w.WriteHeader(response.status)
+			w.Write(response.body.Bytes())
+		default:
+			lockedOut, err := recordLoginFailure(accountKey, accountLoginPolicy)
+			if err == nil {
+				_, err = recordLoginFailure(ipKey, ipLoginPolicy)
+			}
+			if err != nil {
+				log.Printf("❌ Error recording failed login: %v", err)
+			}
+			if lockedOut {
+				log.Printf("⚠️ Logins locked for %s after repeated failures", accountKey)
+				notifyLockout(strings.TrimSpace(credentials.Email))
+			}
+
+			time.Sleep(time.Until(started.Add(minFailedLoginTime)))
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: invalidLoginError})
+		}
+	}
+}
+
+// UnlockLogin lifts the login throttle of an account (users:manage)
+func UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var email string
+	err := db.DB.QueryRow("SELECT email FROM profiles WHERE id = $1", mux.Vars(r)["id"]).Scan(&email)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "User not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	if _, err := db.DB.Exec("DELETE FROM login_throttles WHERE key = $1", accountThrottleKey(email)); err != nil {
+		log.Printf("❌ Error unlocking logins: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to unlock account"})
+		return
+	}
+
+	log.Printf("✅ Logins unlocked for user %s", mux.Vars(r)["id"])
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test failed logins back off exponentially, up to a cap, then lock out
+func TestLoginPolicyWait(t *testing.T) {
+	policy := loginPolicy{freeFailures: 3, maxBackoff: 5 * time.Second, lockoutAfter: 10, lockout: time.Minute}
+
+	for failures, expected := range map[int]time.Duration{0: 0, 3: 0, 4: time.Second, 5: 2 * time.Second, 6: 4 * time.Second, 7: 5 * time.Second, 9: 5 * time.Second} {
+		wait, lockout := policy.wait(failures)
+		assert.Equal(t, expected, wait, "failures: %d", failures)
+		assert.False(t, lockout)
+	}
+
+	wait, lockout := policy.wait(10)
+	assert.Equal(t, time.Minute, wait)
+	assert.True(t, lockout)
+}
+
+// ✅ Test throttle keys ignore case and surrounding spaces in emails
+func TestAccountThrottleKey(t *testing.T) {
+	assert.Equal(t, "account:student@ufl.edu", accountThrottleKey(" Student@UFL.edu "))
+	assert.NotEqual(t, accountThrottleKey("student@ufl.edu"), ipThrottleKey("student@ufl.edu"))
+}
+
+// ✅ Test proxy headers are only trusted when configured
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/auth/login", nil)
+	req.RemoteAddr = "10.0.0.1:52314"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4, 203.0.113.7")
+
+	t.Setenv("TRUST_PROXY_HEADERS", "")
+	assert.Equal(t, "10.0.0.1", clientIP(req))
+
+	t.Setenv("TRUST_PROXY_HEADERS", "true")
+	assert.Equal(t, "203.0.113.7", clientIP(req))
+
+	req.Header.Del("X-Forwarded-For")
+	assert.Equal(t, "10.0.0.1", clientIP(req))
+}
+
+// ✅ Test logins without an email are refused before reaching the handler
+func TestProtectLoginRequiresEmail(t *testing.T) {
+	called := false
+	handler := ProtectLogin(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	for _, body := range []string{`{"password": "secret"}`, `not json`} {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+	assert.False(t, called)
+}
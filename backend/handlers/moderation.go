@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gatorhire/backend/recommend"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Codes of the automated moderation checks
+const (
+	flagMissingSalary      = "missing_salary"
+	flagOffPlatformPayment = "off_platform_payment"
+	flagDuplicateText      = "duplicate_text"
+)
+
+// offPlatformPaymentPattern catches the usual signs of a scam posting:
+// applicants asked to pay, or to move the conversation off GatorHire
+var offPlatformPaymentPattern = regexp.MustCompile(`(?i)\b(wire transfer|western union|moneygram|gift ?cards?|bitcoin|crypto(currency)?|zelle|venmo|cash ?app|(registration|training|application|processing|starter kit) fee|send (us )?money|pay (a|an|the) (fee|deposit)|upfront payment|whatsapp|telegram)\b`)
+
+const (
+	// duplicateTextThreshold is the shingle overlap above which two
+	// descriptions count as the same text
+	duplicateTextThreshold = 0.8
+	// duplicateTextWindow is how far back postings are compared
+	duplicateTextWindow = 180 * 24 * time.Hour
+)
+
+// moderationColumns are selected after jobColumns by scanModerationItem
+const moderationColumns = `moderation_flags, COALESCE(moderation_reason, ''), COALESCE(moderated_by, ''), moderated_at`
+
+// scanModerationItem reads a jobs row selected with jobColumns and moderationColumns
+func scanModerationItem(row rowScanner) (models.ModerationItem, error) {
+	var item models.ModerationItem
+	var flags []byte
+	var moderatedAt sql.NullTime
+
+	job, err := scanJob(extraColumns{row, []interface{}{&flags, &item.Reason, &item.ModeratedBy, &moderatedAt}})
+	if err != nil {
+		return item, err
+	}
+	item.Job = job
+	item.Flags = []models.ModerationFlag{}
+	if len(flags) > 0 {
+		if err := json.Unmarshal(flags, &item.Flags); err != nil {
+			return item, err
+		}
+	}
+	if moderatedAt.Valid {
+		item.ModeratedAt = &moderatedAt.Time
+	}
+	return item, nil
+}
+
+// shingles returns the three-word sequences of a text, which survive small
+// edits better than comparing whole descriptions
+func shingles(text string) map[string]bool {
+	words := recommend.Tokenize(text)
+	set := map[string]bool{}
+	for i := 0; i+3 <= len(words); i++ {
+		set[strings.Join(words[i:i+3], " ")] = true
+	}
+	return set
+}
+
+// moderationFlags runs the automated checks on a posting. others maps the IDs
+// of recent postings to their descriptions, for the duplicate check.
+func moderationFlags(job models.Job, others map[string]string) []models.ModerationFlag {
+	flags := []models.ModerationFlag{}
+
+	if amounts, _ := parseSalary(job.Salary); len(amounts) == 0 {
+		flags = append(flags, models.ModerationFlag{
+			Code:    flagMissingSalary,
+			Message: "The posting does not state a salary or pay rate",
+		})
+	}
+
+	text := strings.Join(append([]string{job.Title, job.Description}, append(job.Requirements, job.Benefits...)...), "\n")
+	if match := offPlatformPaymentPattern.FindString(text); match != "" {
+		flags = append(flags, models.ModerationFlag{
+			Code:    flagOffPlatformPayment,
+			Message: fmt.Sprintf("Mentions %q, which may ask applicants to pay or leave the platform", match),
+		})
+	}
+
+	description := shingles(job.Description)
+	for id, other := range others {
+		if id == job.ID {
+			continue
+		}
+		if jaccard(description, shingles(other)) >= duplicateTextThreshold {
+			flags = append(flags, models.ModerationFlag{
+				Code:    flagDuplicateText,
+				Message: fmt.Sprintf("The description duplicates job %s", id),
+			})
+			break
+		}
+	}
+
+	return flags
+}
+
+// runModerationChecks loads recent postings and runs the automated checks
+func runModerationChecks(job models.Job) ([]models.ModerationFlag, error) {
+	rows, err := db.DB.Query(
+		"SELECT id, description FROM jobs WHERE id <> $1 AND posted_date > $2",
+		job.ID, time.Now().Add(-duplicateTextWindow),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	others := map[string]string{}
+	for rows.Next() {
+		var id, description string
+		if err := rows.Scan(&id, &description); err != nil {
+			return nil, err
+		}
+		others[id] = description
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return moderationFlags(job, others), nil
+}
+
+// decodeSubmission reads and validates a posting submitted by an employer.
+// The company's profile supplies the company name, so employers cannot post
+// under another company's name.
+func decodeSubmission(w http.ResponseWriter, r *http.Request) (models.Job, bool) {
+	var job models.Job
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return job, false
+	}
+	if job.CompanyID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "companyId is required"})
+		return job, false
+	}
+	if !authorize(w, r, rbac.JobsCreate, rbac.Scope{CompanyID: job.CompanyID}) {
+		return job, false
+	}
+
+	err := db.DB.QueryRow("SELECT name FROM companies WHERE id = $1", job.CompanyID).Scan(&job.Company)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Company not found"})
+		return job, false
+	}
+	job.CompanyInfo = nil
+
+	if problems := validateJob(&job); len(problems) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: strings.Join(problems, "; ")})
+		return job, false
+	}
+	return job, true
+}
+
+// saveSubmission writes a submitted posting as pending review, together with
+// its automated check results, a revision and its extracted skills
+func saveSubmission(job models.Job, userID string, create bool) (models.ModerationItem, error) {
+	var item models.ModerationItem
+
+	flags, err := runModerationChecks(job)
+	if err != nil {
+		return item, err
+	}
+	flagData, _ := json.Marshal(flags)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return item, err
+	}
+	defer tx.Rollback()
+
+	if create {
+		job.CreatedBy = userID
+		job.ModerationStatus = models.ModerationPending
+		if err = insertJob(tx, job); err == nil {
+			_, err = tx.Exec("UPDATE jobs SET moderation_flags = $2 WHERE id = $1", job.ID, string(flagData))
+		}
+	} else {
+		_, err = tx.Exec(`
+			UPDATE jobs SET
+				title = $2, company = $3, location = $4, type = $5, salary = $6, description = $7,
+				requirements = $8, responsibilities = $9, benefits = $10, category = $11, status = $12,
+				work_arrangement = $13, remote_regions = $14, remote_time_zones = $15, company_id = $16,
+				screening = $17, application_form = $19, students_only = $20, moderation_status = 'pending', moderation_flags = $18,
+				moderation_reason = NULL, moderated_by = NULL, moderated_at = NULL
+			WHERE id = $1
+		`, job.ID, job.Title, job.Company, job.Location, job.Type, job.Salary, job.Description,
+			jsonList(job.Requirements), jsonList(job.Responsibilities), jsonList(job.Benefits), job.Category, job.Status,
+			job.WorkArrangement, jsonList(job.RemoteRegions), jsonList(job.RemoteTimeZones), job.CompanyID,
+			jsonValue(job.Screening), string(flagData), jsonValue(job.ApplicationForm), job.StudentsOnly)
+	}
+	if err != nil {
+		return item, err
+	}
+
+	item, err = scanModerationItem(tx.QueryRow("SELECT "+jobColumns+", "+moderationColumns+" FROM jobs WHERE id = $1", job.ID))
+	if err != nil {
+		return item, err
+	}
+	if _, err := recordJobRevision(tx, item.Job, userID); err != nil {
+		return item, err
+	}
+	if err := syncJobSkills(tx, item.Job); err != nil {
+		return item, err
+	}
+	if err := tx.Commit(); err != nil {
+		return item, err
+	}
+
+	// A resubmitted posting leaves the public listings until it is approved again
+	jobChanged(item.Job)
+	return item, nil
+}
+
+// SubmitJob lets a company member submit a posting for review. It is not
+// public until a moderator approves it.
+func SubmitJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	job, ok := decodeSubmission(w, r)
+	if !ok {
+		return
+	}
+	job.ID = uuid.New().String()
+	job.PostedDate = time.Now()
+
+	item, err := saveSubmission(job, userID, true)
+	if err != nil {
+		log.Printf("❌ Error submitting job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to submit job"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// ResubmitJob lets the submitter, or anyone else who may update the job,
+// edit a pending or rejected posting, which sends it back to the queue
+func ResubmitJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	scope, err := rbac.JobScope(mux.Vars(r)["id"])
+	if err != nil && err != sql.ErrNoRows {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !authorize(w, r, rbac.JobsUpdate, scope) {
+		return
+	}
+	existing, err := fetchJob(scope.JobID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Submission not found"})
+		return
+	}
+	if existing.ModerationStatus == models.ModerationApproved {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Approved postings can no longer be resubmitted"})
+		return
+	}
+
+	job, ok := decodeSubmission(w, r)
+	if !ok {
+		return
+	}
+	job.ID = existing.ID
+
+	item, err := saveSubmission(job, userID, false)
+	if err != nil {
+		log.Printf("❌ Error resubmitting job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to resubmit job"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(item)
+}
+
+// queryModerationItems runs a moderation query and collects the rows
+func queryModerationItems(query string, args ...interface{}) ([]models.ModerationItem, error) {
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ModerationItem{}
+	for rows.Next() {
+		item, err := scanModerationItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetMySubmissions lists the postings the user submitted and their review state
+func GetMySubmissions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	items, err := queryModerationItems(
+		"SELECT "+jobColumns+", "+moderationColumns+" FROM jobs WHERE created_by = $1 ORDER BY posted_date DESC",
+		userID,
+	)
+	if err != nil {
+		log.Printf("❌ Error querying submissions: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(items)
+}
+
+// GetModerationQueue lists postings by moderation status, oldest first so the
+// queue is worked in order (?status=pending, the default, approved or rejected).
+// Drafts are left out until their author submits them.
+func GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ModerationPending
+	}
+	if status != models.ModerationPending && status != models.ModerationApproved && status != models.ModerationRejected {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid moderation status"})
+		return
+	}
+
+	items, err := queryModerationItems(
+		"SELECT "+jobColumns+", "+moderationColumns+" FROM jobs WHERE moderation_status = $1 AND status <> 'draft' ORDER BY posted_date",
+		status,
+	)
+	if err != nil {
+		log.Printf("❌ Error querying moderation queue: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(items)
+}
+
+// ModerateJob approves or rejects a submitted posting. Approval publishes it
+// with today's posting date, so it reaches alerts and feeds as a new job.
+// Either decision is recorded as a new revision of the job.
+func ModerateJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	moderatorID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var decision models.ModerationDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	decision.Reason = strings.TrimSpace(decision.Reason)
+
+	var query string
+	switch decision.Decision {
+	case "approve":
+		query = `UPDATE jobs SET moderation_status = 'approved', posted_date = NOW(),
+			moderation_reason = NULLIF($2, ''), moderated_by = $3, moderated_at = NOW()
+			WHERE id = $1 AND moderation_status = 'pending'`
+	case "reject":
+		if decision.Reason == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A reason is required when rejecting a posting"})
+			return
+		}
+		query = `UPDATE jobs SET moderation_status = 'rejected',
+			moderation_reason = $2, moderated_by = $3, moderated_at = NOW()
+			WHERE id = $1 AND moderation_status = 'pending'`
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "decision must be approve or reject"})
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	jobID := mux.Vars(r)["id"]
+	result, err := tx.Exec(query, jobID, decision.Reason, moderatorID)
+	if err != nil {
+		log.Printf("❌ Error moderating job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No pending submission with this ID"})
+		return
+	}
+
+	// The decision is part of the job's history, like any other change
+	item, err := scanModerationItem(tx.QueryRow("SELECT "+jobColumns+", "+moderationColumns+" FROM jobs WHERE id = $1", jobID))
+	if err == nil {
+		_, err = recordJobRevision(tx, item.Job, moderatorID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error recording moderated job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	jobChanged(item.Job)
+
+	json.NewEncoder(w).Encode(item)
+}
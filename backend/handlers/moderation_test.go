@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func flagCodes(flags []models.ModerationFlag) []string {
+	codes := []string{}
+	for _, flag := range flags {
+		codes = append(codes, flag.Code)
+	}
+	return codes
+}
+
+// ✅ Test a clean posting passes every automated check
+func TestModerationFlagsClean(t *testing.T) {
+	job := models.Job{
+		ID:          "new",
+		Title:       "Research Assistant",
+		Salary:      "$15/hour",
+		Description: "Help the biology lab run experiments and keep careful records of results.",
+	}
+	others := map[string]string{"old": "Tutor first-year students in calculus and linear algebra each week."}
+	assert.Empty(t, moderationFlags(job, others))
+}
+
+// ✅ Test missing salary, payment requests and copied descriptions are flagged
+func TestModerationFlagsSuspicious(t *testing.T) {
+	description := "Earn money from home processing packages. Contact our manager to get started today with the onboarding."
+	job := models.Job{
+		ID:           "new",
+		Title:        "Package Processor",
+		Salary:       "Competitive",
+		Description:  description,
+		Requirements: []string{"Pay a registration fee via gift card before training"},
+	}
+	others := map[string]string{"new": description, "old": description + " Apply now."}
+
+	flags := moderationFlags(job, others)
+	assert.Equal(t, []string{flagMissingSalary, flagOffPlatformPayment, flagDuplicateText}, flagCodes(flags))
+	assert.Contains(t, flags[2].Message, "old")
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/mailer"
+	"github.com/gatorhire/backend/models"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetTTL is how long a reset link works
+const passwordResetTTL = time.Hour
+
+// maxPasswordResetsPerHour limits reset emails per address, whether or not
+// it belongs to an account
+const maxPasswordResetsPerHour = 3
+
+// minPasswordLength and maxPasswordLength bound accepted passwords; bcrypt
+// only looks at the first 72 bytes
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// RequestPasswordReset emails a single-use reset link to the account with
+// the given email. The response is the same whether or not the account
+// exists, so it can't be used to find out who is registered.
+func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || strings.TrimSpace(request.Email) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Email is required"})
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+
+	var recent int
+	err := db.DB.QueryRow(
+		"SELECT COUNT(*) FROM password_resets WHERE email = $1 AND created_at > $2",
+		email, time.Now().Add(-time.Hour),
+	).Scan(&recent)
+	if err != nil {
+		log.Printf("❌ Error counting password resets: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if recent >= maxPasswordResetsPerHour {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Too many reset requests, please try again later"})
+		return
+	}
+
+	var userID, fullName string
+	err = db.DB.QueryRow("SELECT id, full_name FROM profiles WHERE LOWER(email) = $1", email).Scan(&userID, &fullName)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("❌ Error looking up account for password reset: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	// Requests for unknown addresses are recorded too, so they count
+	// towards the limit the same way
+	token := newToken()
+	var tokenHash interface{}
+	if userID != "" {
+		tokenHash = hashSecretToken(token)
+	}
+	_, err = db.DB.Exec(`
+		INSERT INTO password_resets (id, email, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NOW())
+	`, uuid.New().String(), email, userID, tokenHash, time.Now().Add(passwordResetTTL))
+	if err != nil {
+		log.Printf("❌ Error storing password reset: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	if userID != "" {
+		sendEmail(mailer.Message{
+			To:      email,
+			Subject: "Reset your GatorHire password",
+			Body: "Hi " + fullName + ",\n\n" +
+				"Someone asked to reset the password of your GatorHire account. To choose a new password, open:\n\n" +
+				siteURL() + "/reset-password?token=" + url.QueryEscape(token) + "\n\n" +
+				"The link works once and expires in one hour. If you didn't ask for this, you can ignore this email.\n",
+		})
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// ConfirmPasswordReset sets a new password with a reset token. Using a
+// token invalidates every other outstanding reset link of the account, signs
+// it out everywhere and revokes its API keys, so a key minted by whoever had
+// the old password stops working too.
+func ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Token == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Token and password are required"})
+		return
+	}
+	if len(request.Password) < minPasswordLength || len(request.Password) > maxPasswordLength {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Password must be between 8 and 72 characters"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("❌ Error hashing password: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to reset password"})
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting password reset transaction: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRow(`
+		SELECT user_id FROM password_resets
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, hashSecretToken(request.Token)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This reset link is invalid or has expired"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error looking up password reset: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	_, err = tx.Exec("UPDATE profiles SET password = $2 WHERE id = $1", userID, string(hashedPassword))
+	if err == nil {
+		_, err = tx.Exec("UPDATE password_resets SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID)
+	}
+	if err == nil {
+		_, err = tx.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	}
+	if err == nil {
+		_, err = tx.Exec("UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error resetting password: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to reset password"})
+		return
+	}
+
+	log.Printf("✅ Password reset for user %s", userID)
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
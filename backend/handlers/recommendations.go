@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/recommend"
+	"github.com/gatorhire/backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Weights of the parts of a candidate's profile vector. Skills the candidate
+// lists say the most; each applied or saved job nudges the profile towards
+// similar postings.
+const (
+	skillWeight        = 3.0
+	profileTitleWeight = 2.0
+	bioWeight          = 1.0
+	appliedJobWeight   = 0.5
+	savedJobWeight     = 0.3
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+)
+
+// jobIndex holds the term vectors of all published jobs. It is kept current
+// by indexJob/unindexJob as postings change and resynced periodically by
+// StartRecommendationIndexer.
+var (
+	jobIndex = recommend.NewIndex()
+
+	// jobIndexFingerprints maps indexed job IDs to a hash of their indexed
+	// text, so a resync only re-tokenizes jobs that actually changed
+	jobIndexFingerprints   = map[string]string{}
+	jobIndexFingerprintsMu sync.Mutex
+	jobIndexLoaded         sync.Once
+)
+
+// jobDocument is the part of a job the recommender looks at
+func jobDocument(job models.Job) recommend.Document {
+	return recommend.Document{
+		ID:           job.ID,
+		Title:        job.Title,
+		Body:         job.Description,
+		Requirements: job.Requirements,
+	}
+}
+
+// jobFingerprint hashes the indexed text of a job
+func jobFingerprint(job models.Job) string {
+	sum := sha256.Sum256([]byte(job.Title + "\x00" + job.Description + "\x00" + strings.Join(job.Requirements, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// indexJob adds a job to the recommendation index, or drops it if it is no
+// longer published. It is called through jobChanged.
+func indexJob(job models.Job) {
+	if !jobIsPublished(job) {
+		unindexJob(job.ID)
+		return
+	}
+
+	fingerprint := jobFingerprint(job)
+	jobIndexFingerprintsMu.Lock()
+	defer jobIndexFingerprintsMu.Unlock()
+	if jobIndexFingerprints[job.ID] == fingerprint {
+		return
+	}
+	jobIndex.Upsert(jobDocument(job))
+	jobIndexFingerprints[job.ID] = fingerprint
+}
+
+// unindexJob removes a job from the recommendation index
+func unindexJob(jobID string) {
+	jobIndexFingerprintsMu.Lock()
+	defer jobIndexFingerprintsMu.Unlock()
+	jobIndex.Remove(jobID)
+	delete(jobIndexFingerprints, jobID)
+}
+
+// syncJobIndex brings the index in line with the published jobs in the
+// database: new and edited jobs are (re)indexed, unchanged ones skipped, and
+// jobs that were closed or deleted removed
+func syncJobIndex() error {
+	rows, err := db.DB.Query("SELECT " + jobColumns + " FROM jobs WHERE " + publishedJobCondition)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	published := map[string]bool{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return err
+		}
+		published[job.ID] = true
+		indexJob(job)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	jobIndexFingerprintsMu.Lock()
+	var stale []string
+	for id := range jobIndexFingerprints {
+		if !published[id] {
+			stale = append(stale, id)
+		}
+	}
+	jobIndexFingerprintsMu.Unlock()
+	for _, id := range stale {
+		unindexJob(id)
+	}
+	return nil
+}
+
+// ensureJobIndex loads the index on first use if the background indexer has
+// not done so yet
+func ensureJobIndex() {
+	jobIndexLoaded.Do(func() {
+		if err := syncJobIndex(); err != nil {
+			log.Printf("❌ Error building recommendation index: %v", err)
+		}
+	})
+}
+
+// StartRecommendationIndexer builds the recommendation index and then resyncs
+// it in the background, picking up jobs changed outside the API (SQL, imports
+// on another instance)
+func StartRecommendationIndexer(interval time.Duration) {
+	go func() {
+		ensureJobIndex()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := syncJobIndex(); err != nil {
+				log.Printf("❌ Error syncing recommendation index: %v", err)
+			}
+		}
+	}()
+}
+
+// parseSkills reads the profile's skills JSONB, a list of skill names
+func parseSkills(raw []byte) []string {
+	var skills []string
+	if len(raw) == 0 || json.Unmarshal(raw, &skills) != nil {
+		return nil
+	}
+	return skills
+}
+
+// userJobIDs returns the IDs of the jobs a user has interacted with via the
+// given table (applications, saved_jobs, job_dismissals)
+func userJobIDs(table, userID string) ([]string, error) {
+	rows, err := db.DB.Query("SELECT DISTINCT job_id FROM "+table+" WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// explainRecommendation turns the terms a job shares with the profile into a
+// short reason, naming the candidate's own skills where possible
+func explainRecommendation(terms []string, skillNames map[string]string) (string, []string) {
+	var matched []string
+	seen := map[string]bool{}
+	for _, term := range terms {
+		if name, ok := skillNames[term]; ok && !seen[name] {
+			seen[name] = true
+			matched = append(matched, name)
+		}
+		if len(matched) == 3 {
+			break
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return "Similar to your profile and the jobs you've saved or applied to", nil
+	case 1:
+		return "Because you know " + matched[0], matched
+	default:
+		return "Because you know " + strings.Join(matched[:len(matched)-1], ", ") + " and " + matched[len(matched)-1], matched
+	}
+}
+
+// fetchJobsByID loads published jobs by ID, keyed by ID
+func fetchJobsByID(ids []string) (map[string]models.Job, error) {
+	rows, err := db.DB.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE id = ANY($1) AND "+publishedJobCondition, pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := map[string]models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs[job.ID] = job
+	}
+	return jobs, rows.Err()
+}
+
+// GetJobRecommendations returns the published jobs that best match the
+// candidate's skills, title, bio and application/saved-job history, scored
+// with TF-IDF cosine similarity. Jobs the candidate applied to or dismissed are
+// left out. Without enough profile data it falls back to the newest jobs.
+func GetJobRecommendations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	limit := defaultRecommendationLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxRecommendationLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "limit must be between 1 and 50"})
+			return
+		}
+	}
+
+	var title, bio, workPreference sql.NullString
+	var skills []byte
+	var studentAccess bool
+	err = db.DB.QueryRow(
+		"SELECT title, bio, skills, work_preference, verified_student OR role = 'admin' FROM profiles WHERE id = $1", userID,
+	).Scan(&title, &bio, &skills, &workPreference, &studentAccess)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Profile not found"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error loading profile for recommendations: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	var preference *string
+	if workPreference.Valid {
+		preference = &workPreference.String
+	}
+
+	// Match on canonical skill names so "golang" and "go lang" both find Go jobs
+	profileSkills := parseSkills(skills)
+	if taxonomy, err := currentSkillTaxonomy(); err == nil {
+		profileSkills, _ = normalizeSkills(taxonomy, profileSkills)
+	}
+
+	ensureJobIndex()
+	recommendations, err := recommendJobs(userID, title.String, bio.String, profileSkills, preference, studentAccess, limit)
+	if err != nil {
+		log.Printf("❌ Error recommending jobs for %s: %v", userID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(recommendations)
+}
+
+// recommendJobs scores the index against the candidate's profile and loads the
+// best matching jobs that suit their work preference and that they may see
+func recommendJobs(userID, title, bio string, skills []string, preference *string, studentAccess bool, limit int) ([]models.JobRecommendation, error) {
+	applied, err := userJobIDs("applications", userID)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := userJobIDs("saved_jobs", userID)
+	if err != nil {
+		return nil, err
+	}
+	dismissed, err := userJobIDs("job_dismissals", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := recommend.Vector{}
+	skillNames := map[string]string{}
+	for _, skill := range skills {
+		profile.Add(skill, skillWeight)
+		for _, term := range recommend.Tokenize(skill) {
+			if _, ok := skillNames[term]; !ok {
+				skillNames[term] = skill
+			}
+		}
+	}
+	profile.Add(title, profileTitleWeight)
+	profile.Add(bio, bioWeight)
+	for _, id := range applied {
+		profile.AddVector(jobIndex.DocumentVector(id), appliedJobWeight)
+	}
+	for _, id := range saved {
+		profile.AddVector(jobIndex.DocumentVector(id), savedJobWeight)
+	}
+
+	exclude := map[string]bool{}
+	for _, id := range append(applied, dismissed...) {
+		exclude[id] = true
+	}
+
+	// Over-fetch: some candidates drop out on work preference below
+	results := jobIndex.Query(profile, limit*4, exclude, nil)
+	if len(results) == 0 {
+		return newestJobRecommendations(exclude, preference, studentAccess, limit)
+	}
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	jobs, err := fetchJobsByID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := []models.JobRecommendation{}
+	for _, result := range results {
+		job, ok := jobs[result.ID]
+		if !ok || !jobVisibleTo(job, studentAccess) || len(filterJobsByWorkPreference([]models.Job{job}, preference)) == 0 {
+			continue
+		}
+		explanation, matched := explainRecommendation(result.Terms, skillNames)
+		recommendations = append(recommendations, models.JobRecommendation{
+			Job:           job,
+			Score:         result.Score,
+			Explanation:   explanation,
+			MatchedSkills: matched,
+		})
+		if len(recommendations) == limit {
+			break
+		}
+	}
+	return recommendations, nil
+}
+
+// newestJobRecommendations is the cold-start fallback for candidates whose
+// profile shares no terms with any job
+func newestJobRecommendations(exclude map[string]bool, preference *string, studentAccess bool, limit int) ([]models.JobRecommendation, error) {
+	rows, err := db.DB.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE "+visibleJobCondition(studentAccess)+" ORDER BY posted_date DESC LIMIT $1",
+		limit*4+len(exclude),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !exclude[job.ID] {
+			jobs = append(jobs, job)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	recommendations := []models.JobRecommendation{}
+	for _, job := range filterJobsByWorkPreference(jobs, preference) {
+		recommendations = append(recommendations, models.JobRecommendation{
+			Job:         job,
+			Explanation: "Recently posted",
+		})
+		if len(recommendations) == limit {
+			break
+		}
+	}
+	return recommendations, nil
+}
+
+// DismissJob hides a job from the user's recommendations
+func DismissJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	jobID := mux.Vars(r)["id"]
+	var exists bool
+	if err := db.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1)", jobID).Scan(&exists); err != nil || !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO job_dismissals (user_id, job_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, userID, jobID)
+	if err != nil {
+		log.Printf("❌ Error dismissing job: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// UndismissJob lets a dismissed job be recommended again
+func UndismissJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	_, err = db.DB.Exec("DELETE FROM job_dismissals WHERE user_id = $1 AND job_id = $2", userID, mux.Vars(r)["id"])
+	if err != nil {
+		log.Printf("❌ Error undoing job dismissal: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test explanations name the candidate's own skills, best match first
+func TestExplainRecommendation(t *testing.T) {
+	skillNames := map[string]string{"go": "Golang", "sql": "SQL", "react": "React"}
+
+	explanation, matched := explainRecommendation([]string{"go", "backend", "sql"}, skillNames)
+	assert.Equal(t, "Because you know Golang and SQL", explanation)
+	assert.Equal(t, []string{"Golang", "SQL"}, matched)
+
+	explanation, matched = explainRecommendation([]string{"backend"}, skillNames)
+	assert.Equal(t, "Similar to your profile and the jobs you've saved or applied to", explanation)
+	assert.Nil(t, matched)
+}
+
+// ✅ Test closed jobs are dropped from the index and edits are picked up
+func TestIndexJob(t *testing.T) {
+	job := models.Job{ID: "rec-test", Title: "Go Developer", Status: "active", ModerationStatus: models.ModerationApproved}
+	indexJob(job)
+	assert.True(t, jobIndex.Has(job.ID))
+
+	job.Title = "Python Developer"
+	indexJob(job)
+	assert.Contains(t, jobIndex.DocumentVector(job.ID), "python")
+
+	job.Status = "closed"
+	indexJob(job)
+	assert.False(t, jobIndex.Has(job.ID))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gorilla/mux"
+)
+
+// SetUserRole changes the site-wide role of an account (users:manage). The
+// account's sessions are ended and its API keys revoked, so a removed role
+// stops working right away.
+func SetUserRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || !rbac.ValidRole(rbac.SiteRoles, request.Role) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Role must be user, career_services or admin"})
+		return
+	}
+	userID := mux.Vars(r)["id"]
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow("SELECT role FROM profiles WHERE id = $1 FOR UPDATE", userID).Scan(&current)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if err == nil && current != request.Role {
+		_, err = tx.Exec("UPDATE profiles SET role = $2 WHERE id = $1", userID, request.Role)
+		if err == nil {
+			_, err = tx.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+		}
+		if err == nil {
+			_, err = tx.Exec("UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error changing role of user %s: %v", userID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to change role"})
+		return
+	}
+
+	log.Printf("✅ User %s now has role %s", userID, request.Role)
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
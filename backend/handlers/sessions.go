@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+)
+
+// defaultRefreshTokenTTL is how long a refresh token can be used, unless
+// REFRESH_TOKEN_TTL_DAYS says otherwise
+const defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// refreshTokenTTL returns the configured lifetime of refresh tokens
+func refreshTokenTTL() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_DAYS")); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return defaultRefreshTokenTTL
+}
+
+// hashSecretToken is what is stored for a token that grants access (refresh
+// tokens, reset links); the token itself is only ever known to its holder
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueSessionTokens creates an access token and a refresh token in the
+// given refresh token family; mfa records that the session passed
+// two-factor authentication
+func issueSessionTokens(exec queryExecer, familyID string, user models.User, mfa bool) (models.AuthResponse, error) {
+	grants, err := rbac.LoadGrants(user.ID)
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+	accessToken, _, err := utils.GenerateSessionToken(utils.Claims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: familyID,
+		MFA:       mfa,
+		Grants:    grants,
+	})
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+	refreshToken := newToken()
+	_, err = exec.Exec(`
+		INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, expires_at, mfa, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, uuid.New().String(), familyID, user.ID, hashSecretToken(refreshToken), time.Now().Add(refreshTokenTTL()), mfa)
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	user.Password = ""
+	return models.AuthResponse{
+		Success:      true,
+		User:         user,
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// startSession signs a user in: it starts a new refresh token family and
+// returns the first token pair. Accounts with two-factor authentication get
+// a challenge token instead, which VerifyTwoFactorLogin exchanges for the
+// tokens. Login should respond with it.
+func startSession(user models.User) (models.AuthResponse, error) {
+	var twoFactor bool
+	if err := db.DB.QueryRow("SELECT totp_enabled FROM profiles WHERE id = $1", user.ID).Scan(&twoFactor); err != nil {
+		return models.AuthResponse{}, err
+	}
+	if twoFactor {
+		challengeToken, err := createTwoFactorChallenge(user.ID)
+		if err != nil {
+			return models.AuthResponse{}, err
+		}
+		return models.AuthResponse{Success: true, TwoFactorRequired: true, ChallengeToken: challengeToken}, nil
+	}
+	return issueSessionTokens(db.DB, uuid.New().String(), user, false)
+}
+
+// revokeTokenFamily ends a session: every refresh token in the family stops
+// working, and AuthMiddleware refuses the access tokens issued from it
+func revokeTokenFamily(exec queryExecer, familyID string) error {
+	_, err := exec.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL", familyID)
+	return err
+}
+
+// RefreshSession exchanges a refresh token for a new token pair. Each
+// refresh token works once: presenting one that was already used means it
+// leaked, so the whole family is revoked and the user must log in again.
+func RefreshSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "refreshToken is required"})
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting refresh transaction: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var tokenID, familyID, userID string
+	var expiresAt time.Time
+	var usedAt, revokedAt sql.NullTime
+	var mfa bool
+	err = tx.QueryRow(`
+		SELECT id, family_id, user_id, expires_at, used_at, revoked_at, mfa
+		FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE
+	`, hashSecretToken(request.RefreshToken)).Scan(&tokenID, &familyID, &userID, &expiresAt, &usedAt, &revokedAt, &mfa)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid refresh token"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error looking up refresh token: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	if usedAt.Valid && !revokedAt.Valid {
+		log.Printf("⚠️ Refresh token reuse for user %s; revoking session %s", userID, familyID)
+		if err := revokeTokenFamily(tx, familyID); err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("❌ Error revoking token family: %v", err)
+		}
+	}
+	if usedAt.Valid || revokedAt.Valid || time.Now().After(expiresAt) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Session expired, please log in again"})
+		return
+	}
+
+	// Pick up role changes made since the session started
+	var user models.User
+	err = tx.QueryRow("SELECT id, email, full_name, role, created_at, email_verified FROM profiles WHERE id = $1", userID).
+		Scan(&user.ID, &user.Email, &user.FullName, &user.Role, &user.CreatedAt, &user.EmailVerified)
+	if err != nil {
+		log.Printf("❌ Error fetching user for refresh: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Session expired, please log in again"})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1", tokenID); err != nil {
+		log.Printf("❌ Error rotating refresh token: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	response, err := issueSessionTokens(tx, familyID, user, mfa)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error issuing refreshed tokens: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to refresh session"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// Logout ends the session of the given refresh token and revokes the
+// access token in the Authorization header. Either may be missing or
+// already expired; logging out twice succeeds.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+	}
+	claims, tokenErr := utils.GetClaimsFromToken(r)
+	if request.RefreshToken == "" && tokenErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Nothing to log out"})
+		return
+	}
+
+	var err error
+	if request.RefreshToken != "" {
+		var familyID string
+		err = db.DB.QueryRow("SELECT family_id FROM refresh_tokens WHERE token_hash = $1", hashSecretToken(request.RefreshToken)).Scan(&familyID)
+		if err == nil {
+			err = revokeTokenFamily(db.DB, familyID)
+		} else if err == sql.ErrNoRows {
+			err = nil
+		}
+	}
+	if err == nil && tokenErr == nil {
+		if claims.SessionID != "" {
+			err = revokeTokenFamily(db.DB, claims.SessionID)
+		}
+		if err == nil && claims.ID != "" {
+			_, err = db.DB.Exec(
+				"INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+				claims.ID, claims.ExpiresAt.Time,
+			)
+		}
+	}
+	if err != nil {
+		log.Printf("❌ Error logging out: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to log out"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// cleanupSessions deletes refresh tokens, revocations, password resets,
+// two-factor challenges and SSO logins that have expired and can no longer
+// matter. Revoked families are kept
+// until every token in them has expired, so their access tokens stay
+// refused; resets are kept a day for the per-email rate limit.
+func cleanupSessions() error {
+	if _, err := db.DB.Exec("DELETE FROM revoked_tokens WHERE expires_at < NOW()"); err != nil {
+		return err
+	}
+	if _, err := db.DB.Exec("DELETE FROM password_resets WHERE created_at < $1", time.Now().Add(-24*time.Hour)); err != nil {
+		return err
+	}
+	if _, err := db.DB.Exec("DELETE FROM two_factor_challenges WHERE expires_at < NOW()"); err != nil {
+		return err
+	}
+	if _, err := db.DB.Exec("DELETE FROM oidc_logins WHERE created_at < $1", time.Now().Add(-time.Hour)); err != nil {
+		return err
+	}
+	if _, err := db.DB.Exec(
+		"DELETE FROM login_throttles WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < NOW())",
+		time.Now().Add(-24*time.Hour),
+	); err != nil {
+		return err
+	}
+	_, err := db.DB.Exec(`
+		DELETE FROM refresh_tokens WHERE family_id IN (
+			SELECT family_id FROM refresh_tokens GROUP BY family_id HAVING MAX(expires_at) < $1
+		)
+	`, time.Now().Add(-utils.AccessTokenTTL()))
+	return err
+}
+
+// StartSessionCleanup removes expired session state in the background every interval
+func StartSessionCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := cleanupSessions(); err != nil {
+				log.Printf("❌ Error cleaning up sessions: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gatorhire/backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test only a stable hash of secret tokens is stored
+func TestHashSecretToken(t *testing.T) {
+	first, second := newToken(), newToken()
+	assert.NotEqual(t, hashSecretToken(first), hashSecretToken(second))
+	assert.Equal(t, hashSecretToken(first), hashSecretToken(first))
+	assert.NotEqual(t, first, hashSecretToken(first))
+	assert.Len(t, hashSecretToken(first), 64)
+}
+
+// ✅ Test access tokens carry a unique ID and their session
+func TestSessionAccessToken(t *testing.T) {
+	token, claims, err := utils.GenerateSessionToken(utils.Claims{UserID: "user-1", Email: "a@ufl.edu", Role: "user", SessionID: "family-1"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, claims.ID)
+
+	parsed, err := utils.ValidateToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, claims.ID, parsed.ID)
+	assert.Equal(t, "family-1", parsed.SessionID)
+	assert.WithinDuration(t, parsed.IssuedAt.Add(utils.AccessTokenTTL()), parsed.ExpiresAt.Time, 0)
+
+	_, other, _ := utils.GenerateSessionToken(utils.Claims{UserID: "user-1", Email: "a@ufl.edu", Role: "user", SessionID: "family-1"})
+	assert.NotEqual(t, claims.ID, other.ID)
+}
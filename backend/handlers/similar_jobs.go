@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/recommend"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Weights of the signals that make two postings similar
+const (
+	similarTitleWeight       = 0.35
+	similarRequirementWeight = 0.3
+	similarCategoryWeight    = 0.2
+	similarLocationWeight    = 0.15
+)
+
+const (
+	defaultSimilarLimit = 5
+	maxSimilarLimit     = 20
+	// similarJobsTTL bounds how stale a cached list can get when jobs change
+	// outside the API
+	similarJobsTTL = 15 * time.Minute
+	// similarCandidates is how many text matches are considered besides the
+	// jobs in the same category
+	similarCandidates = 50
+)
+
+type similarJobsEntry struct {
+	jobs    []models.SimilarJob
+	expires time.Time
+}
+
+// similarJobsCache holds the ranked similar jobs of each job ID. Any change to
+// a posting can move it into or out of other jobs' lists, so the whole cache
+// is dropped by invalidateSimilarJobs. The generation counter keeps a list
+// computed before an invalidation from being cached after it.
+var (
+	similarJobsCache      = map[string]similarJobsEntry{}
+	similarJobsGeneration int
+	similarJobsCacheMu    sync.Mutex
+)
+
+// invalidateSimilarJobs empties the similar jobs cache
+func invalidateSimilarJobs() {
+	similarJobsCacheMu.Lock()
+	defer similarJobsCacheMu.Unlock()
+	similarJobsCache = map[string]similarJobsEntry{}
+	similarJobsGeneration++
+}
+
+// jobChanged updates the derived state kept for a job after it was created or
+// edited. CreateJob, UpdateJob and imports call it once their write commits.
+func jobChanged(job models.Job) {
+	indexJob(job)
+	invalidateSimilarJobs()
+}
+
+// jobRemoved drops the derived state kept for a deleted job
+func jobRemoved(jobID string) {
+	unindexJob(jobID)
+	invalidateSimilarJobs()
+}
+
+// termSet returns the distinct terms of the given texts
+func termSet(texts ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, text := range texts {
+		for _, term := range recommend.Tokenize(text) {
+			set[term] = true
+		}
+	}
+	return set
+}
+
+// jaccard is the overlap of two term sets, from 0 (disjoint) to 1 (equal)
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// locationSimilarity is 1 for the same place or two remote jobs, 0.5 for the
+// same state/region ("Gainesville, FL" and "Tampa, FL"), and 0 otherwise
+func locationSimilarity(a, b models.Job) float64 {
+	if a.WorkArrangement == models.WorkArrangementRemote && b.WorkArrangement == models.WorkArrangementRemote {
+		return 1
+	}
+	locationA := strings.ToLower(strings.TrimSpace(a.Location))
+	locationB := strings.ToLower(strings.TrimSpace(b.Location))
+	if locationA == "" || locationB == "" {
+		return 0
+	}
+	if locationA == locationB {
+		return 1
+	}
+	regionA := strings.TrimSpace(locationA[strings.LastIndex(locationA, ",")+1:])
+	regionB := strings.TrimSpace(locationB[strings.LastIndex(locationB, ",")+1:])
+	if strings.Contains(locationA, ",") && strings.Contains(locationB, ",") && regionA == regionB {
+		return 0.5
+	}
+	return 0
+}
+
+// scoreSimilarJob rates how related candidate is to job and lists the signals
+// that matched
+func scoreSimilarJob(job, candidate models.Job) models.SimilarJob {
+	similar := models.SimilarJob{Job: candidate}
+
+	title := jaccard(termSet(job.Title), termSet(candidate.Title))
+	requirements := jaccard(termSet(job.Requirements...), termSet(candidate.Requirements...))
+	var category float64
+	if job.Category != "" && strings.EqualFold(job.Category, candidate.Category) {
+		category = 1
+	}
+	location := locationSimilarity(job, candidate)
+
+	for _, signal := range []struct {
+		name   string
+		value  float64
+		weight float64
+	}{
+		{"title", title, similarTitleWeight},
+		{"requirements", requirements, similarRequirementWeight},
+		{"category", category, similarCategoryWeight},
+		{"location", location, similarLocationWeight},
+	} {
+		similar.Score += signal.value * signal.weight
+		if signal.value > 0 {
+			similar.MatchedOn = append(similar.MatchedOn, signal.name)
+		}
+	}
+	similar.Score = float64(int(similar.Score*1000+0.5)) / 1000
+	return similar
+}
+
+// rankSimilarJobs scores the candidates against job, best first, dropping the
+// job itself, unpublished jobs and candidates with nothing in common
+func rankSimilarJobs(job models.Job, candidates []models.Job, limit int) []models.SimilarJob {
+	ranked := []models.SimilarJob{}
+	for _, candidate := range candidates {
+		if candidate.ID == job.ID || !jobIsPublished(candidate) {
+			continue
+		}
+		if similar := scoreSimilarJob(job, candidate); similar.Score > 0 {
+			ranked = append(ranked, similar)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].PostedDate.After(ranked[j].PostedDate)
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// findSimilarJobs loads the candidates for a job (its category plus the best
+// text matches from the recommendation index) and ranks them
+func findSimilarJobs(job models.Job) ([]models.SimilarJob, error) {
+	ensureJobIndex()
+	var textMatches []string
+	for _, result := range jobIndex.Query(jobDocument(job).Vector(), similarCandidates, map[string]bool{job.ID: true}, nil) {
+		textMatches = append(textMatches, result.ID)
+	}
+
+	rows, err := db.DB.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE "+publishedJobCondition+
+			" AND id <> $1 AND (category = $2 OR id = ANY($3))",
+		job.ID, job.Category, pq.Array(textMatches),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []models.Job
+	for rows.Next() {
+		candidate, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rankSimilarJobs(job, candidates, maxSimilarLimit), nil
+}
+
+// filterStudentOnlyJobs drops the jobs restricted to verified students
+func filterStudentOnlyJobs(jobs []models.SimilarJob) []models.SimilarJob {
+	filtered := []models.SimilarJob{}
+	for _, job := range jobs {
+		if !job.StudentsOnly {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// GetSimilarJobs returns published jobs related to a job by title,
+// requirements, category and location, for the job detail page
+func GetSimilarJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := defaultSimilarLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSimilarLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "limit must be between 1 and 20"})
+			return
+		}
+	}
+
+	// Only jobs the viewer may see have related postings. This is checked
+	// before the cache, so unpublished jobs answer like unknown ones.
+	jobID := mux.Vars(r)["id"]
+	studentAccess := viewerHasStudentAccess(r)
+	var visible bool
+	err := db.DB.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1 AND "+visibleJobCondition(studentAccess)+")", jobID,
+	).Scan(&visible)
+	if err != nil {
+		log.Printf("❌ Error checking job for similar jobs: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !visible {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	similarJobsCacheMu.Lock()
+	entry, ok := similarJobsCache[jobID]
+	generation := similarJobsGeneration
+	similarJobsCacheMu.Unlock()
+
+	if !ok || time.Now().After(entry.expires) {
+		job, err := fetchJob(jobID)
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+			return
+		} else if err != nil {
+			log.Printf("❌ Error fetching job for similar jobs: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+			return
+		}
+
+		similar, err := findSimilarJobs(job)
+		if err != nil {
+			log.Printf("❌ Error finding similar jobs: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+			return
+		}
+
+		entry = similarJobsEntry{jobs: similar, expires: time.Now().Add(similarJobsTTL)}
+		similarJobsCacheMu.Lock()
+		if generation == similarJobsGeneration {
+			similarJobsCache[jobID] = entry
+		}
+		similarJobsCacheMu.Unlock()
+	}
+
+	// The cache is shared by all viewers, so student-only jobs are dropped
+	// here for those who may not see them
+	jobs := entry.jobs
+	if !studentAccess {
+		jobs = filterStudentOnlyJobs(jobs)
+	}
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	json.NewEncoder(w).Encode(jobs)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test locations match exactly, by state, or when both jobs are remote
+func TestLocationSimilarity(t *testing.T) {
+	gainesville := models.Job{Location: "Gainesville, FL", WorkArrangement: models.WorkArrangementOnsite}
+	tampa := models.Job{Location: "Tampa, FL", WorkArrangement: models.WorkArrangementOnsite}
+	austin := models.Job{Location: "Austin, TX", WorkArrangement: models.WorkArrangementOnsite}
+	remote := models.Job{Location: "Remote", WorkArrangement: models.WorkArrangementRemote}
+
+	assert.Equal(t, 1.0, locationSimilarity(gainesville, gainesville))
+	assert.Equal(t, 0.5, locationSimilarity(gainesville, tampa))
+	assert.Equal(t, 0.0, locationSimilarity(gainesville, austin))
+	assert.Equal(t, 1.0, locationSimilarity(remote, remote))
+}
+
+// ✅ Test ranking skips the job itself and closed jobs, best match first
+func TestRankSimilarJobs(t *testing.T) {
+	job := models.Job{ID: "1", Title: "Backend Engineer", Category: "Technology", Status: "active", ModerationStatus: models.ModerationApproved,
+		Location: "Gainesville, FL", Requirements: []string{"Go", "SQL"}}
+	candidates := []models.Job{
+		job,
+		{ID: "2", Title: "Backend Developer", Category: "Technology", Status: "active", ModerationStatus: models.ModerationApproved,
+			Location: "Miami, FL", Requirements: []string{"Golang", "PostgreSQL"}},
+		{ID: "3", Title: "Senior Backend Engineer", Category: "Technology", Status: "active", ModerationStatus: models.ModerationApproved,
+			Location: "Gainesville, FL", Requirements: []string{"Go", "SQL", "Docker"}},
+		{ID: "4", Title: "Backend Engineer", Category: "Technology", Status: "closed",
+			Location: "Gainesville, FL", Requirements: []string{"Go", "SQL"}},
+		{ID: "5", Title: "Registered Nurse", Category: "Healthcare", Status: "active", ModerationStatus: models.ModerationApproved, Location: "Austin, TX"},
+	}
+
+	ranked := rankSimilarJobs(job, candidates, 5)
+	assert.Len(t, ranked, 2)
+	assert.Equal(t, "3", ranked[0].ID)
+	assert.Equal(t, []string{"title", "requirements", "category", "location"}, ranked[0].MatchedOn)
+	assert.Equal(t, "2", ranked[1].ID)
+}
+
+// ✅ Test invalidation empties the cache and bumps its generation
+func TestInvalidateSimilarJobs(t *testing.T) {
+	similarJobsCache["1"] = similarJobsEntry{}
+	generation := similarJobsGeneration
+
+	invalidateSimilarJobs()
+	assert.Empty(t, similarJobsCache)
+	assert.Equal(t, generation+1, similarJobsGeneration)
+}
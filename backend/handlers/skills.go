@@ -0,0 +1,553 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// skillTaxonomyTTL is how long the in-memory taxonomy is used before it is
+// reloaded, so skills added directly in the database show up eventually
+const skillTaxonomyTTL = 10 * time.Minute
+
+// skillKeySeparators is everything that is not part of a skill name: "C++",
+// "C#", "Node.js" and ".NET" keep their punctuation
+var skillKeySeparators = regexp.MustCompile(`[^a-z0-9+#.]+`)
+
+// normalizeSkillKey folds a skill name, alias or piece of text into the form
+// taxonomy keys are stored in: lower case words separated by single spaces
+func normalizeSkillKey(text string) string {
+	words := strings.Fields(skillKeySeparators.ReplaceAllString(strings.ToLower(text), " "))
+	for i, word := range words {
+		// Sentence punctuation: "Go." is Go, ".NET" keeps its leading dot
+		words[i] = strings.TrimRight(word, ".")
+	}
+	return strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+}
+
+// ambiguousSkillKeys are skill names and aliases that are also everyday
+// words ("go above and beyond", "the rest of the team"). In free text they
+// only count as skills in a technical context: see skillContext.
+var ambiguousSkillKeys = map[string]bool{
+	"go": true, "node": true, "rest": true, "ml": true, "tutor": true, "excel": true,
+}
+
+// skillCuesBefore and skillCuesAfter are words that mark a neighbouring
+// ambiguous word as a skill ("experience with Go", "ML models")
+var (
+	skillCuesBefore = map[string]bool{
+		"with": true, "in": true, "using": true, "of": true, "knowledge": true, "experience": true,
+		"proficiency": true, "proficient": true, "including": true, "like": true,
+	}
+	skillCuesAfter = map[string]bool{
+		"developer": true, "developers": true, "engineer": true, "engineers": true, "programming": true,
+		"experience": true, "code": true, "services": true, "backend": true, "api": true, "apis": true,
+		"framework": true, "frameworks": true, "models": true, "language": true, "spreadsheets": true,
+	}
+)
+
+// skillTaxonomy looks up canonical skills by name or alias
+type skillTaxonomy struct {
+	skills   map[string]models.Skill // by ID
+	byKey    map[string]string       // normalized name or alias → skill ID
+	maxWords int                     // longest key, in words
+}
+
+// newSkillTaxonomy indexes skills by their normalized names and aliases
+func newSkillTaxonomy(skills []models.Skill) *skillTaxonomy {
+	taxonomy := &skillTaxonomy{skills: map[string]models.Skill{}, byKey: map[string]string{}, maxWords: 1}
+	for _, skill := range skills {
+		taxonomy.skills[skill.ID] = skill
+		for _, name := range append([]string{skill.Name}, skill.Aliases...) {
+			key := normalizeSkillKey(name)
+			if key == "" {
+				continue
+			}
+			taxonomy.byKey[key] = skill.ID
+			if words := len(strings.Fields(key)); words > taxonomy.maxWords {
+				taxonomy.maxWords = words
+			}
+		}
+	}
+	return taxonomy
+}
+
+// lookup finds the canonical skill for a name, ID or alias
+func (t *skillTaxonomy) lookup(name string) (models.Skill, bool) {
+	if skill, ok := t.skills[strings.TrimSpace(name)]; ok {
+		return skill, true
+	}
+	skill, ok := t.skills[t.byKey[normalizeSkillKey(name)]]
+	return skill, ok
+}
+
+// extract finds the known skills mentioned in free text, in order of first
+// mention. Longer phrases win, so "machine learning" is not read as two words.
+func (t *skillTaxonomy) extract(text string) []models.Skill {
+	words := strings.Fields(normalizeSkillKey(text))
+	var found []models.Skill
+	seen := map[string]bool{}
+	for i := 0; i < len(words); {
+		matched := 0
+		for n := t.maxWords; n > 0; n-- {
+			if i+n > len(words) {
+				continue
+			}
+			key := strings.Join(words[i:i+n], " ")
+			if id, ok := t.byKey[key]; ok {
+				if ambiguousSkillKeys[key] && !t.skillContext(words, i) {
+					continue
+				}
+				if !seen[id] {
+					seen[id] = true
+					found = append(found, t.skills[id])
+				}
+				matched = n
+				break
+			}
+		}
+		if matched == 0 {
+			matched = 1
+		}
+		i += matched
+	}
+	return found
+}
+
+// skillContext reports whether the word at i is used as a skill: it follows
+// or precedes a cue word, or another skill is mentioned within two words
+// ("Python, Go and Java")
+func (t *skillTaxonomy) skillContext(words []string, i int) bool {
+	if i > 0 && skillCuesBefore[words[i-1]] {
+		return true
+	}
+	if i+1 < len(words) && skillCuesAfter[words[i+1]] {
+		return true
+	}
+	for j := i - 2; j <= i+2; j++ {
+		if j < 0 || j >= len(words) || j == i || ambiguousSkillKeys[words[j]] {
+			continue
+		}
+		if _, ok := t.byKey[words[j]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// complete returns the skills whose name or alias starts with prefix, exact
+// matches first, then name matches, then alias and later-word matches
+func (t *skillTaxonomy) complete(prefix, category string, limit int) []models.Skill {
+	prefix = normalizeSkillKey(prefix)
+	rank := map[string]int{}
+	for key, id := range t.byKey {
+		skill := t.skills[id]
+		if category != "" && !strings.EqualFold(skill.Category, category) {
+			continue
+		}
+		r := 4
+		switch {
+		case prefix == "":
+			r = 3
+		case key == prefix:
+			r = 0
+		case strings.HasPrefix(key, prefix) && key == normalizeSkillKey(skill.Name):
+			r = 1
+		case strings.HasPrefix(key, prefix):
+			r = 2
+		case strings.Contains(" "+key, " "+prefix):
+			r = 3
+		}
+		if r == 4 {
+			continue
+		}
+		if best, ok := rank[id]; !ok || r < best {
+			rank[id] = r
+		}
+	}
+
+	matches := make([]models.Skill, 0, len(rank))
+	for id := range rank {
+		matches = append(matches, t.skills[id])
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if rank[matches[i].ID] != rank[matches[j].ID] {
+			return rank[matches[i].ID] < rank[matches[j].ID]
+		}
+		return strings.ToLower(matches[i].Name) < strings.ToLower(matches[j].Name)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+var (
+	skillTaxonomyCache    *skillTaxonomy
+	skillTaxonomyLoadedAt time.Time
+	skillTaxonomyMu       sync.Mutex
+)
+
+// currentSkillTaxonomy returns the cached taxonomy, loading it from the
+// database when it is missing or stale
+func currentSkillTaxonomy() (*skillTaxonomy, error) {
+	skillTaxonomyMu.Lock()
+	defer skillTaxonomyMu.Unlock()
+	if skillTaxonomyCache != nil && time.Since(skillTaxonomyLoadedAt) < skillTaxonomyTTL {
+		return skillTaxonomyCache, nil
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT s.id, s.name, s.category, COALESCE(array_agg(a.alias) FILTER (WHERE a.alias IS NOT NULL), '{}')
+		FROM skills s
+		LEFT JOIN skill_aliases a ON a.skill_id = s.id
+		GROUP BY s.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var skills []models.Skill
+	for rows.Next() {
+		var skill models.Skill
+		if err := rows.Scan(&skill.ID, &skill.Name, &skill.Category, pq.Array(&skill.Aliases)); err != nil {
+			return nil, err
+		}
+		skills = append(skills, skill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	skillTaxonomyCache = newSkillTaxonomy(skills)
+	skillTaxonomyLoadedAt = time.Now()
+	return skillTaxonomyCache, nil
+}
+
+// invalidateSkillTaxonomy forces the next lookup to reload the taxonomy
+func invalidateSkillTaxonomy() {
+	skillTaxonomyMu.Lock()
+	defer skillTaxonomyMu.Unlock()
+	skillTaxonomyCache = nil
+}
+
+// extractJobSkills returns the known skills mentioned in a job's requirements
+func extractJobSkills(taxonomy *skillTaxonomy, requirements []string) []models.Skill {
+	var skills []models.Skill
+	seen := map[string]bool{}
+	for _, requirement := range requirements {
+		for _, skill := range taxonomy.extract(requirement) {
+			if !seen[skill.ID] {
+				seen[skill.ID] = true
+				skills = append(skills, skill)
+			}
+		}
+	}
+	return skills
+}
+
+// syncJobSkills replaces a job's extracted skills. Handlers that write a job's
+// requirements call it in the same transaction.
+func syncJobSkills(exec queryExecer, job models.Job) error {
+	taxonomy, err := currentSkillTaxonomy()
+	if err != nil {
+		return err
+	}
+	if _, err := exec.Exec("DELETE FROM job_skills WHERE job_id = $1", job.ID); err != nil {
+		return err
+	}
+	for _, skill := range extractJobSkills(taxonomy, job.Requirements) {
+		if _, err := exec.Exec("INSERT INTO job_skills (job_id, skill_id) VALUES ($1, $2)", job.ID, skill.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeSkills maps a user's skills onto canonical names ("golang" and
+// "go lang" both become "Go"), dropping duplicates. Skills that are not in
+// the taxonomy are kept as typed. It also returns the IDs of the known skills.
+func normalizeSkills(taxonomy *skillTaxonomy, skills []string) ([]string, []string) {
+	var names, ids []string
+	seen := map[string]bool{}
+	for _, raw := range skills {
+		name := strings.TrimSpace(raw)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if skill, ok := taxonomy.lookup(name); ok {
+			name, key = skill.Name, skill.ID
+			if !seen[key] {
+				ids = append(ids, skill.ID)
+			}
+		}
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	return names, ids
+}
+
+// normalizeProfileSkills normalizes a user's skills and records their skill
+// IDs in profile_skills. The caller stores the returned names in
+// profiles.skills.
+func normalizeProfileSkills(exec queryExecer, userID string, skills []string) ([]string, error) {
+	taxonomy, err := currentSkillTaxonomy()
+	if err != nil {
+		return nil, err
+	}
+	names, ids := normalizeSkills(taxonomy, skills)
+
+	if _, err := exec.Exec("DELETE FROM profile_skills WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, err := exec.Exec("INSERT INTO profile_skills (user_id, skill_id) VALUES ($1, $2)", userID, id); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// unknownSkillError reports a skill filter naming no known skill
+type unknownSkillError struct{ name string }
+
+func (e unknownSkillError) Error() string {
+	return fmt.Sprintf("unknown skill %q", e.name)
+}
+
+// appendSkillFilter adds the skills query parameter to a jobs WHERE clause.
+// It accepts a comma-separated list of skill IDs, names or aliases and
+// matches jobs that ask for any of them. Unknown skills are an
+// unknownSkillError; other errors come from loading the taxonomy.
+func appendSkillFilter(params url.Values, conditions []string, args []interface{}) ([]string, []interface{}, error) {
+	raw := params.Get("skills")
+	if raw == "" {
+		return conditions, args, nil
+	}
+
+	taxonomy, err := currentSkillTaxonomy()
+	if err != nil {
+		return nil, nil, err
+	}
+	var ids []string
+	for _, name := range strings.Split(raw, ",") {
+		skill, ok := taxonomy.lookup(name)
+		if !ok {
+			return nil, nil, unknownSkillError{strings.TrimSpace(name)}
+		}
+		ids = append(ids, skill.ID)
+	}
+
+	args = append(args, pq.Array(ids))
+	conditions = append(conditions, fmt.Sprintf("id IN (SELECT job_id FROM job_skills WHERE skill_id = ANY($%d))", len(args)))
+	return conditions, args, nil
+}
+
+// StartJobSkillBackfill extracts skills for jobs that have none recorded yet,
+// such as jobs created before the taxonomy existed
+func StartJobSkillBackfill() {
+	go func() {
+		rows, err := db.DB.Query("SELECT " + jobColumns + " FROM jobs WHERE NOT EXISTS (SELECT 1 FROM job_skills s WHERE s.job_id = jobs.id)")
+		if err != nil {
+			log.Printf("❌ Error loading jobs for skill backfill: %v", err)
+			return
+		}
+		var jobs []models.Job
+		for rows.Next() {
+			job, err := scanJob(rows)
+			if err != nil {
+				log.Printf("❌ Error scanning job for skill backfill: %v", err)
+				continue
+			}
+			jobs = append(jobs, job)
+		}
+		rows.Close()
+
+		for _, job := range jobs {
+			if err := syncJobSkills(db.DB, job); err != nil {
+				log.Printf("❌ Error extracting skills for job %s: %v", job.ID, err)
+			}
+		}
+	}()
+}
+
+// GetSkills autocompletes skills by name or alias (?q=go&category=Databases&limit=10)
+func GetSkills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 100 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "limit must be between 1 and 100"})
+			return
+		}
+	}
+
+	taxonomy, err := currentSkillTaxonomy()
+	if err != nil {
+		log.Printf("❌ Error loading skills: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(taxonomy.complete(r.URL.Query().Get("q"), r.URL.Query().Get("category"), limit))
+}
+
+// CreateSkill adds a skill and its aliases to the taxonomy (skills:manage)
+func CreateSkill(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var skill models.Skill
+	if err := json.NewDecoder(r.Body).Decode(&skill); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	skill.Name = strings.TrimSpace(skill.Name)
+	skill.Category = strings.TrimSpace(skill.Category)
+	if skill.ID == "" {
+		skill.ID = companySlug(skill.Name)
+	}
+	if skill.Name == "" || skill.Category == "" || skill.ID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Skill name and category are required"})
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO skills (id, name, category) VALUES ($1, $2, $3)", skill.ID, skill.Name, skill.Category); err != nil {
+		log.Printf("❌ Error creating skill: %v", err)
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A skill with this name already exists"})
+		return
+	}
+	for _, alias := range skill.Aliases {
+		if key := normalizeSkillKey(alias); key != "" {
+			if _, err := tx.Exec("INSERT INTO skill_aliases (alias, skill_id) VALUES ($1, $2)", key, skill.ID); err != nil {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(models.ErrorResponse{Error: fmt.Sprintf("Alias %q is already used by another skill", alias)})
+				return
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	invalidateSkillTaxonomy()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(skill)
+}
+
+// SetProfileSkills replaces the candidate's skills. Known skills are stored
+// under their canonical names, so "golang" and "Go" are one skill; the
+// stored list is returned.
+func SetProfileSkills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Skills []string `json:"skills"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	names, err := normalizeProfileSkills(tx, userID, request.Skills)
+	if err == nil {
+		_, err = tx.Exec("UPDATE profiles SET skills = $2 WHERE id = $1", userID, jsonList(names))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error updating profile skills: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update skills"})
+		return
+	}
+
+	if names == nil {
+		names = []string{}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"skills": names})
+}
+
+// GetJobSkills lists the skills extracted from a job's requirements
+func GetJobSkills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := db.DB.Query(`
+		SELECT s.id, s.name, s.category
+		FROM job_skills js
+		JOIN skills s ON s.id = js.skill_id
+		WHERE js.job_id = $1
+		ORDER BY s.name
+	`, mux.Vars(r)["id"])
+	if err != nil {
+		log.Printf("❌ Error querying job skills: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	skills := []models.Skill{}
+	for rows.Next() {
+		var skill models.Skill
+		if err := rows.Scan(&skill.ID, &skill.Name, &skill.Category); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing skill data"})
+			return
+		}
+		skills = append(skills, skill)
+	}
+
+	json.NewEncoder(w).Encode(skills)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSkillTaxonomy() *skillTaxonomy {
+	return newSkillTaxonomy([]models.Skill{
+		{ID: "go", Name: "Go", Category: "Programming Languages", Aliases: []string{"golang", "go lang"}},
+		{ID: "sql", Name: "SQL", Category: "Databases"},
+		{ID: "postgresql", Name: "PostgreSQL", Category: "Databases", Aliases: []string{"postgres"}},
+		{ID: "cpp", Name: "C++", Category: "Programming Languages"},
+		{ID: "machine-learning", Name: "Machine Learning", Category: "Data", Aliases: []string{"ml"}},
+	})
+}
+
+// ✅ Test names and aliases normalize onto one canonical skill
+func TestSkillLookup(t *testing.T) {
+	taxonomy := testSkillTaxonomy()
+	for _, name := range []string{"Go", "golang", "Go Lang", " GO "} {
+		skill, ok := taxonomy.lookup(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, "go", skill.ID, name)
+	}
+	_, ok := taxonomy.lookup("Rust")
+	assert.False(t, ok)
+}
+
+// ✅ Test skills are extracted from requirement sentences, longest phrase first
+func TestExtractJobSkills(t *testing.T) {
+	skills := extractJobSkills(testSkillTaxonomy(), []string{
+		"Experience with Go or similar languages",
+		"Knowledge of SQL databases (Postgres preferred).",
+		"Familiarity with machine learning and C++",
+		"Golang",
+	})
+
+	var ids []string
+	for _, skill := range skills {
+		ids = append(ids, skill.ID)
+	}
+	assert.Equal(t, []string{"go", "sql", "postgresql", "machine-learning", "cpp"}, ids)
+}
+
+// ✅ Test everyday words that are also skill names only count in a technical context
+func TestExtractAmbiguousSkills(t *testing.T) {
+	taxonomy := newSkillTaxonomy([]models.Skill{
+		{ID: "go", Name: "Go", Aliases: []string{"golang"}},
+		{ID: "python", Name: "Python"},
+		{ID: "nodejs", Name: "Node.js", Aliases: []string{"node"}},
+		{ID: "rest-apis", Name: "REST APIs", Aliases: []string{"rest"}},
+		{ID: "machine-learning", Name: "Machine Learning", Aliases: []string{"ml"}},
+		{ID: "tutoring", Name: "Tutoring", Aliases: []string{"tutor"}},
+		{ID: "excel", Name: "Excel", Aliases: []string{"spreadsheets"}},
+	})
+	extract := func(requirement string) []string {
+		ids := []string{}
+		for _, skill := range extractJobSkills(taxonomy, []string{requirement}) {
+			ids = append(ids, skill.ID)
+		}
+		return ids
+	}
+
+	for _, requirement := range []string{
+		"Go above and beyond for our customers",
+		"Keep the rest of the team informed",
+		"Willing to go the extra mile",
+		"Measure 5 ml of solution",
+		"Tutor students after school",
+		"Excel in a fast-paced environment",
+		"Each node reports to the coordinator",
+	} {
+		assert.Empty(t, extract(requirement), requirement)
+	}
+
+	assert.Equal(t, []string{"go"}, extract("Build services in Go"))
+	assert.Equal(t, []string{"python", "go"}, extract("Python, Go"))
+	assert.Equal(t, []string{"nodejs"}, extract("Node backend experience"))
+	assert.Equal(t, []string{"machine-learning"}, extract("Deploy ML models"))
+	assert.Equal(t, []string{"rest-apis"}, extract("Experience with REST"))
+	assert.Equal(t, []string{"excel"}, extract("Proficient in Excel"))
+	assert.Equal(t, []string{"go"}, extract("Golang"))
+}
+
+// ✅ Test user skills are canonicalized and de-duplicated, unknown ones kept
+func TestNormalizeSkills(t *testing.T) {
+	names, ids := normalizeSkills(testSkillTaxonomy(), []string{"golang", "Go", "Postgres", "Rust", "rust", ""})
+	assert.Equal(t, []string{"Go", "PostgreSQL", "Rust"}, names)
+	assert.Equal(t, []string{"go", "postgresql"}, ids)
+}
+
+// ✅ Test autocomplete ranks exact and name matches before alias matches
+func TestSkillComplete(t *testing.T) {
+	taxonomy := testSkillTaxonomy()
+
+	matches := taxonomy.complete("go", "", 10)
+	assert.Equal(t, "go", matches[0].ID)
+
+	matches = taxonomy.complete("post", "", 10)
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "PostgreSQL", matches[0].Name)
+
+	matches = taxonomy.complete("learn", "", 10)
+	assert.Equal(t, "machine-learning", matches[0].ID)
+
+	assert.Len(t, taxonomy.complete("", "Databases", 10), 2)
+}
@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/oidc"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ssoLoginTTL is how long a user has to come back from the identity provider
+const ssoLoginTTL = 10 * time.Minute
+
+// ssoCodeTTL is how long the site has to exchange the one-time code it is
+// sent back with for tokens
+const ssoCodeTTL = time.Minute
+
+// ssoTimeout bounds each request to an identity provider
+const ssoTimeout = 10 * time.Second
+
+// Providers are discovered on first use and kept for the life of the process
+var (
+	ssoProvidersMu sync.Mutex
+	ssoProviders   = map[string]*oidc.Provider{}
+)
+
+var errUnknownSSOProvider = errors.New("unknown SSO provider")
+
+// ssoProvider returns the configured identity provider with the given ID
+func ssoProvider(ctx context.Context, id string) (*oidc.Provider, error) {
+	ssoProvidersMu.Lock()
+	defer ssoProvidersMu.Unlock()
+	if provider, ok := ssoProviders[id]; ok {
+		return provider, nil
+	}
+	for _, config := range oidc.ConfigsFromEnv() {
+		if config.ID != id {
+			continue
+		}
+		provider, err := oidc.Discover(ctx, config, &http.Client{Timeout: ssoTimeout})
+		if err != nil {
+			return nil, err
+		}
+		ssoProviders[id] = provider
+		return provider, nil
+	}
+	return nil, errUnknownSSOProvider
+}
+
+// ssoRedirectURI is the callback registered with the identity provider
+func ssoRedirectURI(providerID string) string {
+	return apiBaseURL() + "/auth/oidc/" + url.PathEscape(providerID) + "/callback"
+}
+
+// safeRedirectPath keeps post-login redirects on the site
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// siteRedirect builds a URL on the site with extra query parameters
+func siteRedirect(path string, params url.Values) string {
+	target, err := url.Parse(siteURL() + safeRedirectPath(path))
+	if err != nil {
+		target, _ = url.Parse(siteURL() + "/")
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// beginSSOLogin records a login attempt and returns the provider URL to send
+// the user to. linkUserID is set when a signed-in user links the identity to
+// their account instead of logging in.
+func beginSSOLogin(ctx context.Context, providerID, linkUserID, redirectPath string) (string, error) {
+	provider, err := ssoProvider(ctx, providerID)
+	if err != nil {
+		return "", err
+	}
+
+	state, nonce, verifier := oidc.RandomString(), oidc.RandomString(), oidc.RandomString()
+	_, err = db.DB.Exec(`
+		INSERT INTO oidc_logins (id, provider, state_hash, nonce, code_verifier, link_user_id, redirect_path, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NOW())
+	`, uuid.New().String(), providerID, hashSecretToken(state), nonce, verifier, linkUserID,
+		safeRedirectPath(redirectPath), time.Now().Add(ssoLoginTTL))
+	if err != nil {
+		return "", err
+	}
+	return provider.AuthCodeURL(ssoRedirectURI(providerID), state, nonce, verifier), nil
+}
+
+// writeSSOError answers a failed beginSSOLogin
+func writeSSOError(w http.ResponseWriter, providerID string, err error) {
+	if err == errUnknownSSOProvider {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unknown sign-in provider"})
+		return
+	}
+	log.Printf("❌ Error starting SSO login with %s: %v", providerID, err)
+	w.WriteHeader(http.StatusBadGateway)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "The sign-in provider is not available"})
+}
+
+// GetSSOProviders lists the identity providers users can log in with
+func GetSSOProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	providers := []models.SSOProvider{}
+	for _, config := range oidc.ConfigsFromEnv() {
+		providers = append(providers, models.SSOProvider{
+			ID:       config.ID,
+			Name:     config.Name,
+			LoginURL: apiBaseURL() + "/auth/oidc/" + url.PathEscape(config.ID) + "/login",
+		})
+	}
+	json.NewEncoder(w).Encode(providers)
+}
+
+// StartSSOLogin sends the browser to the identity provider. ?redirect= is
+// the site path to return to afterwards.
+func StartSSOLogin(w http.ResponseWriter, r *http.Request) {
+	providerID := mux.Vars(r)["provider"]
+	ctx, cancel := context.WithTimeout(r.Context(), ssoTimeout)
+	defer cancel()
+
+	authURL, err := beginSSOLogin(ctx, providerID, "", r.URL.Query().Get("redirect"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeSSOError(w, providerID, err)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// LinkSSOAccount returns the provider URL at which the current user links
+// an identity to their account, so they can log in with it from then on
+func LinkSSOAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+	var request struct {
+		Redirect string `json:"redirect"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+	}
+
+	providerID := mux.Vars(r)["provider"]
+	ctx, cancel := context.WithTimeout(r.Context(), ssoTimeout)
+	defer cancel()
+	authURL, err := beginSSOLogin(ctx, providerID, userID, request.Redirect)
+	if err != nil {
+		writeSSOError(w, providerID, err)
+		return
+	}
+	json.NewEncoder(w).Encode(models.SSOAuthorization{AuthorizationURL: authURL})
+}
+
+// SSOCallback is where the identity provider sends the browser back. The
+// identity is mapped to an account and the browser continues to the site's
+// /login/sso page with a one-time code, which CompleteSSOLogin exchanges for
+// tokens; failures go to /login with ?sso_error=.
+func SSOCallback(w http.ResponseWriter, r *http.Request) {
+	providerID := mux.Vars(r)["provider"]
+	query := r.URL.Query()
+	fail := func(reason string) {
+		http.Redirect(w, r, siteRedirect("/login", url.Values{"sso_error": {reason}}), http.StatusSeeOther)
+	}
+
+	// The state works once, whatever the outcome
+	var loginID, nonce, verifier, redirectPath string
+	var linkUserID sql.NullString
+	err := db.DB.QueryRow(`
+		UPDATE oidc_logins SET used_at = NOW()
+		WHERE state_hash = $1 AND provider = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, nonce, code_verifier, link_user_id, redirect_path
+	`, hashSecretToken(query.Get("state")), providerID).Scan(&loginID, &nonce, &verifier, &linkUserID, &redirectPath)
+	if err == sql.ErrNoRows {
+		fail("expired")
+		return
+	} else if err != nil {
+		log.Printf("❌ Error looking up SSO login: %v", err)
+		fail("failed")
+		return
+	}
+	if query.Get("error") != "" || query.Get("code") == "" {
+		fail("cancelled")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), ssoTimeout)
+	defer cancel()
+	provider, err := ssoProvider(ctx, providerID)
+	var claims *oidc.Claims
+	if err == nil {
+		claims, err = provider.Exchange(ctx, query.Get("code"), ssoRedirectURI(providerID), verifier)
+	}
+	if err == nil && claims.Nonce != nonce {
+		err = errors.New("nonce mismatch")
+	}
+	if err != nil {
+		log.Printf("❌ SSO login with %s failed: %v", providerID, err)
+		fail("failed")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting SSO transaction: %v", err)
+		fail("failed")
+		return
+	}
+	defer tx.Rollback()
+
+	if linkUserID.Valid {
+		problem, err := linkSSOIdentity(tx, providerID, claims, linkUserID.String)
+		if err == nil && problem == "" {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("❌ Error linking %s identity: %v", providerID, err)
+			fail("failed")
+			return
+		}
+		if problem != "" {
+			fail(problem)
+			return
+		}
+		log.Printf("✅ Linked %s identity to user %s", providerID, linkUserID.String)
+		http.Redirect(w, r, siteRedirect(redirectPath, url.Values{"sso_linked": {providerID}}), http.StatusSeeOther)
+		return
+	}
+
+	userID, problem, err := resolveSSOUser(tx, providerID, claims)
+	code := newToken()
+	if err == nil && problem == "" {
+		_, err = tx.Exec(
+			"UPDATE oidc_logins SET user_id = $2, login_code_hash = $3, completed_at = NOW() WHERE id = $1",
+			loginID, userID, hashSecretToken(code),
+		)
+	}
+	if err == nil && problem == "" {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error signing in with %s: %v", providerID, err)
+		fail("failed")
+		return
+	}
+	if problem != "" {
+		fail(problem)
+		return
+	}
+
+	http.Redirect(w, r, siteRedirect("/login/sso", url.Values{"code": {code}, "redirect": {redirectPath}}), http.StatusSeeOther)
+}
+
+// resolveSSOUser finds the account an identity belongs to. Known identities
+// log in to their account; otherwise a verified email links the identity to
+// the account with that email, and new emails get a new account. It returns
+// a problem code for the site when the identity can't be used.
+func resolveSSOUser(tx *sql.Tx, providerID string, claims *oidc.Claims) (string, string, error) {
+	var userID string
+	err := tx.QueryRow(`
+		UPDATE user_identities SET email = $3, last_login_at = NOW()
+		WHERE provider = $1 AND subject = $2
+		RETURNING user_id
+	`, providerID, claims.Subject, claims.Email).Scan(&userID)
+	if err == nil {
+		return userID, "", nil
+	} else if err != sql.ErrNoRows {
+		return "", "", err
+	}
+
+	if claims.Email == "" {
+		return "", "no_email", nil
+	}
+
+	var emailVerified bool
+	err = tx.QueryRow("SELECT id, email_verified FROM profiles WHERE LOWER(email) = $1", claims.Email).Scan(&userID, &emailVerified)
+	switch {
+	case err == sql.ErrNoRows:
+		userID, err = createSSOUser(tx, claims)
+		if err != nil {
+			return "", "", err
+		}
+		log.Printf("✅ Created account %s for %s identity", userID, providerID)
+	case err != nil:
+		return "", "", err
+	case !claims.EmailVerified:
+		// Without proof the identity owns the address, the account's owner
+		// has to log in with their password and link it themselves
+		return "", "email_in_use", nil
+	case !emailVerified:
+		// Nobody proved they own this account's address until now, so
+		// whoever chose its password may not be the owner: make the
+		// password unusable, end its sessions and revoke its API keys before
+		// handing it over
+		password, err := unusablePassword()
+		if err == nil {
+			_, err = tx.Exec("UPDATE profiles SET password = $2, email_verified = TRUE WHERE id = $1", userID, password)
+		}
+		if err == nil {
+			_, err = tx.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+		}
+		if err == nil {
+			_, err = tx.Exec("UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if err := insertSSOIdentity(tx, providerID, claims, userID); err != nil {
+		return "", "", err
+	}
+	if claims.EmailVerified && isStudentEmail(claims.Email) {
+		// Another account being the student with this address must not
+		// stop the login
+		if err = markVerifiedStudent(tx, userID, claims.Email); err == errStudentEmailClaimed {
+			log.Printf("⚠️ Student address of user %s is already used by another account", userID)
+			err = nil
+		}
+	}
+	return userID, "", err
+}
+
+// createSSOUser creates an account just in time for a new identity. It has
+// no usable password; the user can set one through a password reset.
+func createSSOUser(tx *sql.Tx, claims *oidc.Claims) (string, error) {
+	password, err := unusablePassword()
+	if err != nil {
+		return "", err
+	}
+	fullName := strings.TrimSpace(claims.Name)
+	if fullName == "" {
+		fullName = claims.Email[:strings.Index(claims.Email+"@", "@")]
+	}
+
+	userID := uuid.New().String()
+	_, err = tx.Exec(`
+		INSERT INTO profiles (id, email, password, full_name, role, email_verified, created_at)
+		VALUES ($1, $2, $3, $4, 'user', $5, NOW())
+	`, userID, claims.Email, password, fullName, claims.EmailVerified)
+	if err == nil && !claims.EmailVerified {
+		err = sendVerificationEmail(tx, userID, claims.Email, fullName)
+	}
+	return userID, err
+}
+
+// linkSSOIdentity links an identity to a signed-in user's account, replacing
+// any identity they had at that provider
+func linkSSOIdentity(tx *sql.Tx, providerID string, claims *oidc.Claims, userID string) (string, error) {
+	var owner string
+	err := tx.QueryRow("SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2", providerID, claims.Subject).Scan(&owner)
+	if err == nil {
+		if owner != userID {
+			return "already_linked", nil
+		}
+		return "", nil
+	} else if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_identities WHERE provider = $1 AND user_id = $2", providerID, userID); err != nil {
+		return "", err
+	}
+	return "", insertSSOIdentity(tx, providerID, claims, userID)
+}
+
+func insertSSOIdentity(tx *sql.Tx, providerID string, claims *oidc.Claims, userID string) error {
+	_, err := tx.Exec(`
+		INSERT INTO user_identities (provider, subject, user_id, email, created_at, last_login_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NOW(), NOW())
+	`, providerID, claims.Subject, userID, claims.Email)
+	return err
+}
+
+// unusablePassword is a password hash nobody knows the password of
+func unusablePassword() (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(newToken()), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CompleteSSOLogin exchanges the one-time code from SSOCallback for a
+// session, or a two-factor challenge if the account uses it
+func CompleteSSOLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Code == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "code is required"})
+		return
+	}
+
+	var user models.User
+	err := db.DB.QueryRow(`
+		WITH login AS (
+			UPDATE oidc_logins SET login_code_hash = NULL
+			WHERE login_code_hash = $1 AND completed_at > $2
+			RETURNING user_id
+		)
+		SELECT p.id, p.email, p.full_name, p.role, p.created_at, p.email_verified, p.verified_student
+		FROM profiles p JOIN login ON login.user_id = p.id
+	`, hashSecretToken(request.Code), time.Now().Add(-ssoCodeTTL)).
+		Scan(&user.ID, &user.Email, &user.FullName, &user.Role, &user.CreatedAt, &user.EmailVerified, &user.VerifiedStudent)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This sign-in link has expired, please try again"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error completing SSO login: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	response, err := startSession(user)
+	if err != nil {
+		log.Printf("❌ Error starting session after SSO login: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to log in"})
+		return
+	}
+	json.NewEncoder(w).Encode(response)
+}
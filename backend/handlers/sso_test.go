@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test post-login redirects stay on the site
+func TestSafeRedirectPath(t *testing.T) {
+	assert.Equal(t, "/jobs/42", safeRedirectPath("/jobs/42"))
+	assert.Equal(t, "/", safeRedirectPath(""))
+	assert.Equal(t, "/", safeRedirectPath("https://evil.example.com"))
+	assert.Equal(t, "/", safeRedirectPath("//evil.example.com"))
+	assert.Equal(t, "/", safeRedirectPath("/\\evil.example.com"))
+}
+
+// ✅ Test site redirects keep the path's query and add the outcome
+func TestSiteRedirect(t *testing.T) {
+	t.Setenv("PUBLIC_SITE_URL", "https://gatorhire.example.com")
+	assert.Equal(t,
+		"https://gatorhire.example.com/settings?sso_linked=ufl&tab=security",
+		siteRedirect("/settings?tab=security", url.Values{"sso_linked": {"ufl"}}),
+	)
+	assert.Equal(t,
+		"https://gatorhire.example.com/?sso_error=expired",
+		siteRedirect("//evil.example.com", url.Values{"sso_error": {"expired"}}),
+	)
+}
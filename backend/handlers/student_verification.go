@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/mailer"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+	"github.com/lib/pq"
+)
+
+// defaultStudentDomains are trusted unless TRUSTED_STUDENT_DOMAINS says otherwise
+const defaultStudentDomains = "ufl.edu"
+
+// trustedStudentDomains returns the university domains whose addresses
+// prove someone is a student, from the comma-separated
+// TRUSTED_STUDENT_DOMAINS
+func trustedStudentDomains() []string {
+	value := os.Getenv("TRUSTED_STUDENT_DOMAINS")
+	if strings.TrimSpace(value) == "" {
+		value = defaultStudentDomains
+	}
+	var domains []string
+	for _, domain := range strings.Split(value, ",") {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "@."); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// isStudentEmail reports whether the address is on a trusted university
+// domain or one of its subdomains (e.g. cise.ufl.edu)
+func isStudentEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, trusted := range trustedStudentDomains() {
+		if domain == trusted || strings.HasSuffix(domain, "."+trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+// errStudentEmailClaimed is returned by markVerifiedStudent when another
+// account is already a verified student through the address
+var errStudentEmailClaimed = errors.New("student email address is used by another account")
+
+// markVerifiedStudent makes the user a verified student through the given
+// university address, unless another account already claimed it
+func markVerifiedStudent(exec queryExecer, userID, email string) error {
+	result, err := exec.Exec(`
+		UPDATE profiles SET verified_student = TRUE, student_email = $2
+		WHERE id = $1 AND NOT EXISTS (
+			SELECT 1 FROM profiles WHERE LOWER(student_email) = LOWER($2) AND id <> $1
+		)
+	`, userID, email)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errStudentEmailClaimed
+	}
+	return nil
+}
+
+// userHasStudentAccess reports whether the user may see and apply to jobs
+// restricted to verified students; admins always can
+func userHasStudentAccess(userID string) (bool, error) {
+	var access bool
+	err := db.DB.QueryRow("SELECT verified_student OR role = 'admin' FROM profiles WHERE id = $1", userID).Scan(&access)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return access, err
+}
+
+// usersWithStudentAccess returns which of the users have student access
+func usersWithStudentAccess(userIDs []string) (map[string]bool, error) {
+	access := make(map[string]bool)
+	if len(userIDs) == 0 {
+		return access, nil
+	}
+	rows, err := db.DB.Query(
+		"SELECT id FROM profiles WHERE id = ANY($1) AND (verified_student OR role = 'admin')",
+		pq.Array(userIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		access[id] = true
+	}
+	return access, rows.Err()
+}
+
+// viewerHasStudentAccess is userHasStudentAccess for the optional bearer
+// token of a public request; anonymous viewers don't have access
+func viewerHasStudentAccess(r *http.Request) bool {
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		return false
+	}
+	access, err := userHasStudentAccess(userID)
+	if err != nil {
+		log.Printf("⚠️ Error checking student access for user %s: %v", userID, err)
+		return false
+	}
+	return access
+}
+
+// RequestStudentVerification makes the current user a verified student. An
+// already verified account address on a trusted domain counts at once;
+// any other university address gets a confirmation link first.
+func RequestStudentVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	address, err := mail.ParseAddress(strings.TrimSpace(request.Email))
+	if err != nil || address.Name != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A valid email address is required"})
+		return
+	}
+	email := strings.ToLower(address.Address)
+	if !isStudentEmail(email) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error: "Use your university email address (" + strings.Join(trustedStudentDomains(), ", ") + ")",
+		})
+		return
+	}
+
+	var accountEmail, fullName string
+	var emailVerified, verifiedStudent, claimed bool
+	var studentEmail sql.NullString
+	err = db.DB.QueryRow(`
+		SELECT email, full_name, email_verified, verified_student, student_email,
+			EXISTS (SELECT 1 FROM profiles o WHERE LOWER(o.student_email) = $2 AND o.id <> p.id)
+		FROM profiles p WHERE id = $1
+	`, userID, email).Scan(&accountEmail, &fullName, &emailVerified, &verifiedStudent, &studentEmail, &claimed)
+	if err != nil {
+		log.Printf("❌ Error fetching profile for student verification: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if verifiedStudent && strings.EqualFold(studentEmail.String, email) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "You are already verified with this address"})
+		return
+	}
+	if claimed {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This address is already used by another account"})
+		return
+	}
+
+	if emailVerified && strings.EqualFold(accountEmail, email) {
+		if err := markVerifiedStudent(db.DB, userID, email); err == errStudentEmailClaimed {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This address is already used by another account"})
+			return
+		} else if err != nil {
+			log.Printf("❌ Error verifying student: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+			return
+		}
+		log.Printf("✅ User %s verified as a student", userID)
+		json.NewEncoder(w).Encode(models.StudentVerificationResponse{Success: true, VerifiedStudent: true})
+		return
+	}
+
+	var sentToday int
+	var lastSent sql.NullTime
+	err = db.DB.QueryRow(
+		"SELECT COUNT(*), MAX(created_at) FROM email_verifications WHERE user_id = $1 AND purpose = $2 AND created_at > $3",
+		userID, verifyStudentEmail, time.Now().Add(-24*time.Hour),
+	).Scan(&sentToday, &lastSent)
+	if err != nil {
+		log.Printf("❌ Error counting student verification emails: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if wait := verificationResendWait(sentToday, lastSent, time.Now()); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Please wait before requesting another verification email"})
+		return
+	}
+
+	token, err := createEmailVerification(db.DB, userID, email, verifyStudentEmail)
+	if err != nil {
+		log.Printf("❌ Error storing student verification: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to send verification email"})
+		return
+	}
+	sendEmail(mailer.Message{
+		To:      email,
+		Subject: "Confirm your student email address",
+		Body: "Hi " + fullName + ",\n\n" +
+			"Please confirm this address to verify your GatorHire account as a student. " +
+			"Verified students can see and apply to student-only jobs:\n\n" +
+			apiBaseURL() + "/auth/verify-email?token=" + url.QueryEscape(token) + "\n\n" +
+			"The link expires in two days. If you didn't ask for this, you can ignore this email.\n",
+	})
+
+	json.NewEncoder(w).Encode(models.StudentVerificationResponse{Success: true, VerifiedStudent: false})
+}
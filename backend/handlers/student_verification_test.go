@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/gatorhire/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test that trusted domains and their subdomains count as student addresses
+func TestIsStudentEmail(t *testing.T) {
+	t.Setenv("TRUSTED_STUDENT_DOMAINS", "")
+	assert.True(t, isStudentEmail("albert@ufl.edu"))
+	assert.True(t, isStudentEmail("albert@CISE.UFL.EDU"))
+	assert.False(t, isStudentEmail("albert@notufl.edu"))
+	assert.False(t, isStudentEmail("albert@ufl.edu.example.com"))
+	assert.False(t, isStudentEmail("ufl.edu"))
+
+	t.Setenv("TRUSTED_STUDENT_DOMAINS", " ufl.edu, @fsu.edu ")
+	assert.Equal(t, []string{"ufl.edu", "fsu.edu"}, trustedStudentDomains())
+	assert.True(t, isStudentEmail("seminole@fsu.edu"))
+}
+
+// ✅ Test that student-only jobs are hidden from viewers without student access
+func TestJobVisibleTo(t *testing.T) {
+	job := models.Job{Status: "active", ModerationStatus: models.ModerationApproved, StudentsOnly: true}
+	assert.True(t, jobVisibleTo(job, true))
+	assert.False(t, jobVisibleTo(job, false))
+
+	job.StudentsOnly = false
+	assert.True(t, jobVisibleTo(job, false))
+
+	assert.Equal(t, publishedJobCondition, visibleJobCondition(true))
+	assert.Contains(t, visibleJobCondition(false), "NOT students_only")
+}
+
+// updateExecer stands in for the database in markVerifiedStudent, updating
+// the given number of rows
+type updateExecer int64
+
+func (u updateExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return sqlResult(u), nil
+}
+
+func (u updateExecer) QueryRow(query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+type sqlResult int64
+
+func (r sqlResult) LastInsertId() (int64, error) { return 0, nil }
+func (r sqlResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+// ✅ Test addresses another account already claimed are reported, not ignored
+func TestMarkVerifiedStudentClaimed(t *testing.T) {
+	assert.Nil(t, markVerifiedStudent(updateExecer(1), "user-1", "albert@ufl.edu"))
+	assert.Equal(t, errStudentEmailClaimed, markVerifiedStudent(updateExecer(0), "user-1", "albert@ufl.edu"))
+}
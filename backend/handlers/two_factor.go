@@ -0,0 +1,535 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/totp"
+	"github.com/gatorhire/backend/utils"
+	"github.com/google/uuid"
+)
+
+// twoFactorIssuer names the account in authenticator apps
+const twoFactorIssuer = "GatorHire"
+
+// twoFactorChallengeTTL is how long a user has to enter their code after
+// their password was accepted
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// totpSkew accepts codes from one 30-second step either side, for clock drift
+const totpSkew = 1
+
+// After five wrong codes in a row, codes are refused for 15 minutes
+const (
+	maxTwoFactorFailures = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+// recoveryCodeCount is how many single-use recovery codes a user gets
+const recoveryCodeCount = 10
+
+// twoFactorRequest carries a code from the authenticator app or, when the
+// phone is lost, a recovery code
+type twoFactorRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recoveryCode"`
+}
+
+// createTwoFactorChallenge records that a user's password was accepted and
+// returns the token that completes the login together with a code
+func createTwoFactorChallenge(userID string) (string, error) {
+	token := newToken()
+	_, err := db.DB.Exec(`
+		INSERT INTO two_factor_challenges (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`, uuid.New().String(), userID, hashSecretToken(token), time.Now().Add(twoFactorChallengeTTL))
+	return token, err
+}
+
+// normalizeRecoveryCode accepts recovery codes with or without the dash
+// and in any case
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+// generateRecoveryCodes replaces the user's recovery codes with new ones
+// and returns them; only their hashes are stored
+func generateRecoveryCodes(exec queryExecer, userID string) ([]string, error) {
+	if _, err := exec.Exec("DELETE FROM two_factor_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code := newToken()[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		_, err := exec.Exec(`
+			INSERT INTO two_factor_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES ($1, $2, $3, NOW())
+		`, uuid.New().String(), userID, hashSecretToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// verifySecondFactor checks a code from the user's authenticator app, or
+// uses up one of their recovery codes. TOTP codes work once. With enrolling
+// set, the code is checked against the secret from SetupTwoFactor that is
+// not enabled yet, and recovery codes don't count. Wrong codes are counted
+// in the transaction, so callers commit it either way.
+func verifySecondFactor(tx *sql.Tx, userID string, request twoFactorRequest, enrolling bool) (bool, error) {
+	var secret sql.NullString
+	var enabled bool
+	var lastStep int64
+	var failures int
+	var failedAt sql.NullTime
+	err := tx.QueryRow(`
+		SELECT totp_secret, totp_enabled, totp_last_step, totp_failures, totp_failed_at
+		FROM profiles WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&secret, &enabled, &lastStep, &failures, &failedAt)
+	if err != nil {
+		return false, err
+	}
+	if !secret.Valid || enabled == enrolling {
+		return false, nil
+	}
+	if failures >= maxTwoFactorFailures && failedAt.Valid && time.Since(failedAt.Time) < twoFactorLockout {
+		return false, nil
+	}
+
+	ok := false
+	if request.RecoveryCode != "" && !enrolling {
+		result, err := tx.Exec(
+			"UPDATE two_factor_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+			userID, hashSecretToken(normalizeRecoveryCode(request.RecoveryCode)),
+		)
+		if err != nil {
+			return false, err
+		}
+		affected, _ := result.RowsAffected()
+		ok = affected == 1
+	} else if step, valid := totp.Validate(secret.String, request.Code, time.Now(), totpSkew); valid && step > lastStep {
+		lastStep = step
+		ok = true
+	}
+
+	if ok {
+		_, err = tx.Exec("UPDATE profiles SET totp_last_step = $2, totp_failures = 0, totp_failed_at = NULL WHERE id = $1", userID, lastStep)
+	} else {
+		_, err = tx.Exec("UPDATE profiles SET totp_failures = totp_failures + 1, totp_failed_at = NOW() WHERE id = $1", userID)
+	}
+	return ok, err
+}
+
+// stepUpToken issues an access token for the current session that records
+// it passed two-factor authentication; refreshed tokens keep the flag
+func stepUpToken(exec queryExecer, claims *utils.Claims) (string, error) {
+	if claims.SessionID != "" {
+		if _, err := exec.Exec("UPDATE refresh_tokens SET mfa = TRUE WHERE family_id = $1", claims.SessionID); err != nil {
+			return "", err
+		}
+	}
+	session := *claims
+	session.MFA = true
+	token, _, err := utils.GenerateSessionToken(session)
+	return token, err
+}
+
+// decodeTwoFactorRequest reads the request body, writing the error itself
+func decodeTwoFactorRequest(w http.ResponseWriter, r *http.Request) (twoFactorRequest, bool) {
+	var request twoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || (request.Code == "" && request.RecoveryCode == "") {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "A code or recovery code is required"})
+		return request, false
+	}
+	return request, true
+}
+
+// GetTwoFactorStatus tells the current user whether two-factor
+// authentication is on, and whether their role requires it
+func GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, role, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	status := models.TwoFactorStatus{Required: role == "admin" && utils.AdminTwoFactorRequired()}
+	err = db.DB.QueryRow(`
+		SELECT p.totp_enabled,
+			(SELECT COUNT(*) FROM two_factor_recovery_codes c WHERE c.user_id = p.id AND c.used_at IS NULL)
+		FROM profiles p WHERE p.id = $1
+	`, userID).Scan(&status.Enabled, &status.RecoveryCodesRemaining)
+	if err != nil {
+		log.Printf("❌ Error fetching two-factor status: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
+// SetupTwoFactor starts enrollment: it creates a new secret and returns it
+// with the otpauth:// URI to show as a QR code. Nothing changes for the
+// user until EnableTwoFactor confirms a code from the app.
+func SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("❌ Error generating TOTP secret: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to set up two-factor authentication"})
+		return
+	}
+
+	var email string
+	err = db.DB.QueryRow(`
+		UPDATE profiles SET totp_secret = $2, totp_last_step = 0
+		WHERE id = $1 AND NOT totp_enabled
+		RETURNING email
+	`, userID, secret).Scan(&email)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Two-factor authentication is already enabled"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error storing TOTP secret: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(twoFactorIssuer, email, secret),
+	})
+}
+
+// EnableTwoFactor finishes enrollment with a code from the authenticator
+// app. It returns the recovery codes, shown to the user this once, and a
+// step-up token for the current session.
+func EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, err := utils.GetClaimsFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+	request, ok := decodeTwoFactorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting two-factor transaction: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	valid, err := verifySecondFactor(tx, claims.UserID, request, true)
+	if err == nil && !valid {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error checking two-factor code: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !valid {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid code, or two-factor authentication is not being set up"})
+		return
+	}
+
+	var codes []string
+	var token string
+	_, err = tx.Exec("UPDATE profiles SET totp_enabled = TRUE WHERE id = $1", claims.UserID)
+	if err == nil {
+		codes, err = generateRecoveryCodes(tx, claims.UserID)
+	}
+	if err == nil {
+		token, err = stepUpToken(tx, claims)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error enabling two-factor authentication: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to enable two-factor authentication"})
+		return
+	}
+
+	log.Printf("✅ Two-factor authentication enabled for user %s", claims.UserID)
+	json.NewEncoder(w).Encode(models.TwoFactorResponse{
+		Success:       true,
+		Token:         token,
+		ExpiresIn:     int(utils.AccessTokenTTL().Seconds()),
+		RecoveryCodes: codes,
+	})
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a
+// code. Admins can't turn it off while REQUIRE_ADMIN_2FA is set.
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, role, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+	if role == "admin" && utils.AdminTwoFactorRequired() {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Two-factor authentication is required for admin accounts"})
+		return
+	}
+	request, ok := decodeTwoFactorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting two-factor transaction: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	valid, err := verifySecondFactor(tx, userID, request, false)
+	if err == nil && valid {
+		_, err = tx.Exec(`
+			UPDATE profiles SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = 0
+			WHERE id = $1
+		`, userID)
+		if err == nil {
+			_, err = tx.Exec("DELETE FROM two_factor_recovery_codes WHERE user_id = $1", userID)
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error disabling two-factor authentication: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to disable two-factor authentication"})
+		return
+	}
+	if !valid {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid code"})
+		return
+	}
+
+	log.Printf("✅ Two-factor authentication disabled for user %s", userID)
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes after
+// checking a code from their authenticator app
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+	request, ok := decodeTwoFactorRequest(w, r)
+	if !ok {
+		return
+	}
+	// Recovery codes can't be used to mint new ones
+	request.RecoveryCode = ""
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting two-factor transaction: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var codes []string
+	valid, err := verifySecondFactor(tx, userID, request, false)
+	if err == nil && valid {
+		codes, err = generateRecoveryCodes(tx, userID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error regenerating recovery codes: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to regenerate recovery codes"})
+		return
+	}
+	if !valid {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid code"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.TwoFactorResponse{Success: true, RecoveryCodes: codes})
+}
+
+// VerifyTwoFactorLogin completes a login that Login answered with a
+// challenge token: with a valid code it starts the session, marked as having
+// passed two-factor authentication
+func VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	request, ok := decodeTwoFactorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting two-factor transaction: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var challengeID, userID string
+	err = tx.QueryRow(`
+		SELECT id, user_id FROM two_factor_challenges
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, hashSecretToken(request.ChallengeToken)).Scan(&challengeID, &userID)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "This sign-in attempt has expired, please log in again"})
+		return
+	} else if err != nil {
+		log.Printf("❌ Error looking up two-factor challenge: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+
+	valid, err := verifySecondFactor(tx, userID, request, false)
+	if err == nil && !valid {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error checking two-factor code: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid code"})
+		return
+	}
+
+	var user models.User
+	var response models.AuthResponse
+	err = tx.QueryRow("SELECT id, email, full_name, role, created_at, email_verified, verified_student FROM profiles WHERE id = $1", userID).
+		Scan(&user.ID, &user.Email, &user.FullName, &user.Role, &user.CreatedAt, &user.EmailVerified, &user.VerifiedStudent)
+	if err == nil {
+		_, err = tx.Exec("UPDATE two_factor_challenges SET used_at = NOW() WHERE id = $1", challengeID)
+	}
+	if err == nil {
+		response, err = issueSessionTokens(tx, uuid.New().String(), user, true)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error completing two-factor login: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to log in"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// StepUpTwoFactor checks a code for a session that is already signed in,
+// e.g. one that started before two-factor authentication was enabled, and
+// returns a step-up token for it
+func StepUpTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, err := utils.GetClaimsFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+	request, ok := decodeTwoFactorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Printf("❌ Error starting two-factor transaction: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var token string
+	valid, err := verifySecondFactor(tx, claims.UserID, request, false)
+	if err == nil && valid {
+		token, err = stepUpToken(tx, claims)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("❌ Error stepping up session: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if !valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid code"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.TwoFactorResponse{
+		Success:   true,
+		Token:     token,
+		ExpiresIn: int(utils.AccessTokenTTL().Seconds()),
+	})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gatorhire/backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test recovery codes are accepted however they are typed
+func TestNormalizeRecoveryCode(t *testing.T) {
+	assert.Equal(t, "a1b2c3d4e5", normalizeRecoveryCode("A1B2C-3D4E5"))
+	assert.Equal(t, "a1b2c3d4e5", normalizeRecoveryCode(" a1b2c 3d4e5 "))
+}
+
+// ✅ Test step-up tokens carry the MFA claim, and admin 2FA is opt-in
+func TestStepUpClaims(t *testing.T) {
+	token, _, err := utils.GenerateSessionToken(utils.Claims{UserID: "user-1", Email: "a@ufl.edu", Role: "admin", SessionID: "family-1", MFA: true})
+	assert.Nil(t, err)
+	claims, err := utils.ValidateToken(token)
+	assert.Nil(t, err)
+	assert.True(t, claims.MFA)
+
+	t.Setenv("REQUIRE_ADMIN_2FA", "")
+	assert.False(t, utils.AdminTwoFactorRequired())
+	t.Setenv("REQUIRE_ADMIN_2FA", "true")
+	assert.True(t, utils.AdminTwoFactorRequired())
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/utils"
+)
+
+// normalizeWorkArrangement maps user-supplied spellings ("On-site", "WFH", ...)
+// onto one of the models.WorkArrangement* constants. It returns "" for
+// anything it does not recognise.
+func normalizeWorkArrangement(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "remote", "wfh", "work from home", "fully remote":
+		return models.WorkArrangementRemote
+	case "hybrid":
+		return models.WorkArrangementHybrid
+	case "onsite", "on-site", "on site", "in-person", "in person", "office":
+		return models.WorkArrangementOnsite
+	}
+	return ""
+}
+
+// inferWorkArrangement guesses a job's work arrangement from the free-text
+// location and type fields, mirroring db/migrations/001_work_arrangement.sql.
+// It is used for jobs that arrive without an explicit workArrangement.
+func inferWorkArrangement(location, jobType string) string {
+	text := strings.ToLower(location + " " + jobType)
+	switch {
+	case strings.Contains(text, "hybrid"):
+		return models.WorkArrangementHybrid
+	case strings.Contains(text, "remote"),
+		strings.Contains(text, "work from home"),
+		strings.Contains(text, "anywhere"):
+		return models.WorkArrangementRemote
+	}
+	return models.WorkArrangementOnsite
+}
+
+// applyWorkArrangementDefaults validates job.WorkArrangement, falling back to
+// inferWorkArrangement when it is empty. Regions and time zones only make
+// sense for jobs that can be done away from the office, so they are cleared
+// for on-site roles.
+func applyWorkArrangementDefaults(job *models.Job) error {
+	if job.WorkArrangement == "" {
+		job.WorkArrangement = inferWorkArrangement(job.Location, job.Type)
+	} else {
+		normalized := normalizeWorkArrangement(job.WorkArrangement)
+		if normalized == "" {
+			return fmt.Errorf("invalid work arrangement %q", job.WorkArrangement)
+		}
+		job.WorkArrangement = normalized
+	}
+
+	if job.WorkArrangement == models.WorkArrangementOnsite {
+		job.RemoteRegions = nil
+		job.RemoteTimeZones = nil
+	}
+	return nil
+}
+
+// arrangementsForPreference returns the work arrangements a candidate with the
+// given preference should be shown. Someone who wants to work remotely only
+// sees remote jobs, a hybrid candidate also accepts fully remote roles, and an
+// on-site candidate also accepts hybrid ones. A nil result means no filtering.
+func arrangementsForPreference(preference *string) []string {
+	if preference == nil {
+		return nil
+	}
+	switch normalizeWorkArrangement(*preference) {
+	case models.WorkArrangementRemote:
+		return []string{models.WorkArrangementRemote}
+	case models.WorkArrangementHybrid:
+		return []string{models.WorkArrangementHybrid, models.WorkArrangementRemote}
+	case models.WorkArrangementOnsite:
+		return []string{models.WorkArrangementOnsite, models.WorkArrangementHybrid}
+	}
+	return nil
+}
+
+// filterJobsByWorkPreference drops jobs whose work arrangement does not suit
+// the candidate's stated preference. It is applied to recommendation results.
+func filterJobsByWorkPreference(jobs []models.Job, preference *string) []models.Job {
+	allowed := arrangementsForPreference(preference)
+	if allowed == nil {
+		return jobs
+	}
+
+	var filtered []models.Job
+	for _, job := range jobs {
+		for _, arrangement := range allowed {
+			if job.WorkArrangement == arrangement {
+				filtered = append(filtered, job)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// appendWorkArrangementFilter adds the workArrangement and remoteRegion query
+// parameters to a jobs WHERE clause. workArrangement accepts a comma-separated
+// list ("remote,hybrid"); remoteRegion matches jobs that either list the
+// region or do not restrict regions at all.
+func appendWorkArrangementFilter(params url.Values, conditions []string, args []interface{}) ([]string, []interface{}, error) {
+	if raw := params.Get("workArrangement"); raw != "" {
+		var placeholders []string
+		for _, value := range strings.Split(raw, ",") {
+			arrangement := normalizeWorkArrangement(value)
+			if arrangement == "" {
+				return nil, nil, fmt.Errorf("invalid work arrangement %q", value)
+			}
+			args = append(args, arrangement)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		conditions = append(conditions, fmt.Sprintf("work_arrangement IN (%s)", strings.Join(placeholders, ", ")))
+	}
+
+	if region := params.Get("remoteRegion"); region != "" {
+		args = append(args, strings.ToUpper(strings.TrimSpace(region)))
+		conditions = append(conditions, fmt.Sprintf(
+			"(work_arrangement <> 'onsite' AND (remote_regions IS NULL OR jsonb_array_length(remote_regions) = 0 OR remote_regions ? $%d))",
+			len(args),
+		))
+	}
+
+	return conditions, args, nil
+}
+
+// SetWorkPreference stores the work arrangement the candidate prefers, which
+// narrows their recommendations. A null or empty workPreference clears it.
+func SetWorkPreference(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, _, err := utils.GetUserFromToken(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Unauthorized"})
+		return
+	}
+
+	var request struct {
+		WorkPreference *string `json:"workPreference"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	var preference *string
+	if request.WorkPreference != nil && strings.TrimSpace(*request.WorkPreference) != "" {
+		normalized := normalizeWorkArrangement(*request.WorkPreference)
+		if normalized == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Work preference must be remote, hybrid or onsite"})
+			return
+		}
+		preference = &normalized
+	}
+
+	result, err := db.DB.Exec("UPDATE profiles SET work_preference = $2 WHERE id = $1", userID, preference)
+	if err != nil {
+		log.Printf("❌ Error updating work preference: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update work preference"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Profile not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.SuccessResponse{Success: true})
+}
+
+// GetWorkArrangementFacets returns the number of active jobs per work
+// arrangement, narrowed by the same category/type/location filters as search
+func GetWorkArrangementFacets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.URL.Query()
+	conditions := []string{visibleJobCondition(viewerHasStudentAccess(r))}
+	var args []interface{}
+
+	if category := params.Get("category"); category != "" && category != "All" {
+		args = append(args, category)
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(args)))
+	}
+	if jobType := params.Get("type"); jobType != "" {
+		args = append(args, jobType)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if location := params.Get("location"); location != "" {
+		args = append(args, "%"+location+"%")
+		conditions = append(conditions, fmt.Sprintf("location ILIKE $%d", len(args)))
+	}
+
+	// The facet itself is not filtered by workArrangement, only by region
+	params.Del("workArrangement")
+	conditions, args, err := appendWorkArrangementFilter(params, conditions, args)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT work_arrangement, COUNT(*)
+		FROM jobs
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY work_arrangement
+	`, args...)
+	if err != nil {
+		log.Printf("❌ Error querying work arrangement facets: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	facets := map[string]int{
+		models.WorkArrangementRemote: 0,
+		models.WorkArrangementHybrid: 0,
+		models.WorkArrangementOnsite: 0,
+	}
+	for rows.Next() {
+		var arrangement string
+		var count int
+		if err := rows.Scan(&arrangement, &count); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Error parsing facet data"})
+			return
+		}
+		facets[arrangement] = count
+	}
+
+	json.NewEncoder(w).Encode(facets)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gatorhire/backend/models"
+)
+
+// ✅ Test inferring work arrangements from legacy free-text fields
+func TestInferWorkArrangement(t *testing.T) {
+	assert.Equal(t, models.WorkArrangementRemote, inferWorkArrangement("Remote - US", "Full-time"))
+	assert.Equal(t, models.WorkArrangementRemote, inferWorkArrangement("Gainesville, FL", "Remote"))
+	assert.Equal(t, models.WorkArrangementHybrid, inferWorkArrangement("Hybrid (2 days remote)", "Internship"))
+	assert.Equal(t, models.WorkArrangementOnsite, inferWorkArrangement("New York, NY", "Full-time"))
+}
+
+// ✅ Test candidate preferences map to the arrangements they accept
+func TestArrangementsForPreference(t *testing.T) {
+	remote, hybrid, onsite, unknown := "remote", "Hybrid", "on-site", "whatever"
+
+	assert.Nil(t, arrangementsForPreference(nil))
+	assert.Nil(t, arrangementsForPreference(&unknown))
+	assert.Equal(t, []string{"remote"}, arrangementsForPreference(&remote))
+	assert.Equal(t, []string{"hybrid", "remote"}, arrangementsForPreference(&hybrid))
+	assert.Equal(t, []string{"onsite", "hybrid"}, arrangementsForPreference(&onsite))
+
+	jobs := []models.Job{
+		{ID: "1", WorkArrangement: "remote"},
+		{ID: "2", WorkArrangement: "onsite"},
+	}
+	filtered := filterJobsByWorkPreference(jobs, &remote)
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, "1", filtered[0].ID)
+}
+
+// ✅ Test building the search filter from query parameters
+func TestAppendWorkArrangementFilter(t *testing.T) {
+	params := url.Values{"workArrangement": {"remote,hybrid"}, "remoteRegion": {"us"}}
+	conditions, args, err := appendWorkArrangementFilter(params, []string{"status = 'active'"}, nil)
+	assert.Nil(t, err)
+	assert.Len(t, conditions, 3)
+	assert.Equal(t, "work_arrangement IN ($1, $2)", conditions[1])
+	assert.Equal(t, []interface{}{"remote", "hybrid", "US"}, args)
+
+	_, _, err = appendWorkArrangementFilter(url.Values{"workArrangement": {"moon"}}, nil, nil)
+	assert.NotNil(t, err)
+}
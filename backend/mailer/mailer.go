@@ -0,0 +1,146 @@
+// Package mailer sends transactional email (password resets, verification
+// links, security notices). Production uses SMTP; development writes each
+// message to a directory or the console so flows can be tested offline.
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// format renders a message as RFC 5322 text
+func format(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// headerValue keeps user-supplied text from adding headers
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+// SMTP sends mail through an SMTP server, authenticating when a username is set
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send implements Mailer
+func (s SMTP) Send(msg Message) error {
+	sender, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %v", s.From, err)
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(s.Host+":"+s.Port, auth, sender.Address, []string{msg.To}, format(s.From, msg, time.Now()))
+}
+
+// File writes each message to its own .eml file in Dir
+type File struct {
+	Dir  string
+	From string
+}
+
+// Send implements Mailer
+func (f File) Send(msg Message) error {
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405.000000000"), sanitizeFilename(msg.To))
+	return os.WriteFile(filepath.Join(f.Dir, name), format(f.From, msg, now), 0o600)
+}
+
+func sanitizeFilename(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, value)
+}
+
+// Console prints each message to Out
+type Console struct {
+	Out  io.Writer
+	From string
+
+	mu sync.Mutex
+}
+
+// Send implements Mailer
+func (c *Console) Send(msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := fmt.Fprintf(c.Out, "📧 ----\n%s\n---- 📧\n", format(c.From, msg, time.Now()))
+	return err
+}
+
+// FromEnv builds the mailer selected by MAILER ("smtp", "file" or
+// "console", the default). SMTP reads SMTP_HOST, SMTP_PORT, SMTP_USERNAME
+// and SMTP_PASSWORD; file writes to MAIL_DIR. MAIL_FROM is the sender.
+func FromEnv() Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "GatorHire <no-reply@gatorhire.local>"
+	}
+
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return SMTP{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		return File{Dir: dir, From: from}
+	case "", "console":
+		return &Console{Out: os.Stdout, From: from}
+	default:
+		log.Printf("⚠️ Unknown MAILER %q, printing emails to the console", os.Getenv("MAILER"))
+		return &Console{Out: os.Stdout, From: from}
+	}
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test messages are formatted without letting fields inject headers
+func TestFormat(t *testing.T) {
+	raw := string(format("GatorHire <no-reply@gatorhire.local>", Message{
+		To:      "student@ufl.edu",
+		Subject: "Hello\r\nBcc: attacker@example.com",
+		Body:    "Line one\nLine two",
+	}, time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)))
+
+	assert.Contains(t, raw, "To: student@ufl.edu\r\n")
+	assert.Contains(t, raw, "Subject: Hello  Bcc: attacker@example.com\r\n")
+	assert.NotContains(t, raw, "\r\nBcc:")
+	assert.True(t, strings.HasSuffix(raw, "\r\n\r\nLine one\r\nLine two"))
+}
+
+// ✅ Test the file mailer writes one .eml file per message
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := File{Dir: filepath.Join(dir, "mail"), From: "no-reply@gatorhire.local"}
+	assert.Nil(t, mailer.Send(Message{To: "a@ufl.edu", Subject: "One", Body: "1"}))
+	assert.Nil(t, mailer.Send(Message{To: "a@ufl.edu", Subject: "Two", Body: "2"}))
+
+	files, err := os.ReadDir(filepath.Join(dir, "mail"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	assert.True(t, strings.HasSuffix(files[0].Name(), "-a@ufl.edu.eml"))
+}
+
+// ✅ Test the console mailer prints the message
+func TestConsoleMailer(t *testing.T) {
+	var out bytes.Buffer
+	mailer := &Console{Out: &out, From: "no-reply@gatorhire.local"}
+	assert.Nil(t, mailer.Send(Message{To: "a@ufl.edu", Subject: "Reset your password", Body: "https://example.com"}))
+	assert.Contains(t, out.String(), "Subject: Reset your password")
+	assert.Contains(t, out.String(), "https://example.com")
+}
@@ -2,14 +2,42 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 
+	"github.com/gatorhire/backend/apikey"
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/models"
+	"github.com/gatorhire/backend/rbac"
 	"github.com/gatorhire/backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
 )
 
-// AuthMiddleware verifies the JWT token in the Authorization header
+// AuthMiddleware verifies the JWT token in the Authorization header. It
+// accepts a personal API key in its place, for routes that check a
+// permission: keys are limited to their scopes.
 func AuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, true, false)
+}
+
+// SessionMiddleware is AuthMiddleware for a user's own account, which only
+// signed-in sessions may use, not API keys
+func SessionMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, false, false)
+}
+
+// TwoFactorSetupMiddleware is SessionMiddleware for the two-factor endpoints:
+// it also admits admins who have yet to pass two-factor authentication when
+// REQUIRE_ADMIN_2FA is set, so they can enroll and step up
+func TwoFactorSetupMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, false, true)
+}
+
+func authenticate(next http.Handler, allowAPIKey, allowPendingTwoFactor bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get Authorization header
 		authHeader := r.Header.Get("Authorization")
@@ -25,23 +53,100 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Validate token
-		claims, err := utils.ValidateToken(tokenParts[1])
-		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
-			return
+		var claims *utils.Claims
+		if apikey.IsKey(tokenParts[1]) {
+			if !allowAPIKey {
+				http.Error(w, "API keys cannot be used for this endpoint", http.StatusForbidden)
+				return
+			}
+
+			// Look up the key
+			var twoFactorEnabled bool
+			var err error
+			claims, twoFactorEnabled, err = apiKeyClaims(tokenParts[1])
+			if err == sql.ErrNoRows {
+				http.Error(w, "Invalid or expired API key", http.StatusUnauthorized)
+				return
+			} else if err != nil {
+				log.Printf("❌ Error checking API key: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			// A key can't pass two-factor authentication itself, so admin
+			// keys only work for accounts that have it enabled. That covers
+			// keys created before REQUIRE_ADMIN_2FA was set.
+			if claims.Role == "admin" && !twoFactorEnabled && utils.AdminTwoFactorRequired() {
+				http.Error(w, "Two-factor authentication required", http.StatusForbidden)
+				return
+			}
+		} else {
+			// Validate token
+			var err error
+			claims, err = utils.ValidateToken(tokenParts[1])
+			if err != nil {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			// Reject tokens revoked by logout or by refresh token reuse
+			revoked, err := utils.TokenRevoked(claims)
+			if err != nil {
+				log.Printf("❌ Error checking token revocation: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			// Admin sessions must have passed two-factor authentication
+			if claims.Role == "admin" && !claims.MFA && !allowPendingTwoFactor && utils.AdminTwoFactorRequired() {
+				http.Error(w, "Two-factor authentication required", http.StatusForbidden)
+				return
+			}
 		}
 
 		// Add user info to request context
 		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
 		ctx = context.WithValue(ctx, "userEmail", claims.Email)
 		ctx = context.WithValue(ctx, "userRole", claims.Role)
+		ctx = context.WithValue(ctx, "userMFA", claims.MFA)
+		ctx = context.WithValue(ctx, "claims", claims)
 
 		// Call the next handler with the updated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// apiKeyClaims returns the claims of a live API key, and whether its owner
+// has two-factor authentication enabled, and records that it was used, at
+// most once a minute. Unknown, revoked and expired keys are sql.ErrNoRows.
+func apiKeyClaims(key string) (*utils.Claims, bool, error) {
+	claims := &utils.Claims{}
+	var scopes []string
+	var twoFactorEnabled bool
+	err := db.DB.QueryRow(`
+		SELECT k.id, k.scopes, p.id, p.email, p.role, p.totp_enabled
+		FROM api_keys k
+		JOIN profiles p ON p.id = k.user_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())
+	`, apikey.Hash(key)).Scan(&claims.APIKeyID, pq.Array(&scopes), &claims.UserID, &claims.Email, &claims.Role, &twoFactorEnabled)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, scope := range scopes {
+		claims.Scopes = append(claims.Scopes, rbac.Permission(scope))
+	}
+
+	_, err = db.DB.Exec(`
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, claims.APIKeyID)
+	return claims, twoFactorEnabled, err
+}
+
 // AdminMiddleware checks if the user has admin role
 func AdminMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -55,4 +160,87 @@ func AdminMiddleware(next http.Handler) http.Handler {
 		// Call the next handler
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+// ScopeFunc tells RequirePermission what a request acts on
+type ScopeFunc func(r *http.Request) (rbac.Scope, error)
+
+// JobFromVar scopes a request to the job whose ID is the named route variable
+func JobFromVar(name string) ScopeFunc {
+	return func(r *http.Request) (rbac.Scope, error) {
+		return jobScope(mux.Vars(r)[name])
+	}
+}
+
+// JobFromQuery scopes a request to the job whose ID is the named query parameter
+func JobFromQuery(name string) ScopeFunc {
+	return func(r *http.Request) (rbac.Scope, error) {
+		return jobScope(r.URL.Query().Get(name))
+	}
+}
+
+// jobScope leaves reporting jobs that do not exist to the handler, once the
+// user is known to be allowed to find out
+func jobScope(jobID string) (rbac.Scope, error) {
+	scope, err := rbac.JobScope(jobID)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return scope, err
+}
+
+// CompanyFromVar scopes a request to the company whose ID is the named route variable
+func CompanyFromVar(name string) ScopeFunc {
+	return func(r *http.Request) (rbac.Scope, error) {
+		return rbac.Scope{CompanyID: mux.Vars(r)[name]}, nil
+	}
+}
+
+// RequirePermission lets a request through if the user holds the permission
+// in the request's scope. A nil scope means the permission must be held
+// site-wide. It runs after AuthMiddleware, and answers in JSON like the
+// handlers do, so refusals look the same whichever of them refuses.
+func RequirePermission(permission rbac.Permission, scope ScopeFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value("claims").(*utils.Claims)
+			if !ok {
+				writeError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			if !claims.ScopeAllows(permission) {
+				writeError(w, http.StatusForbidden, "This API key is not allowed to "+string(permission))
+				return
+			}
+
+			var target rbac.Scope
+			if scope != nil {
+				var err error
+				if target, err = scope(r); err != nil {
+					log.Printf("❌ Error resolving permission scope: %v", err)
+					writeError(w, http.StatusInternalServerError, "Database error")
+					return
+				}
+			}
+
+			allowed, err := rbac.Authorize(claims.UserID, claims.Role, permission, target)
+			if err != nil {
+				log.Printf("❌ Error checking permission %s: %v", permission, err)
+				writeError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+			if !allowed {
+				writeError(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: message})
+}
@@ -4,50 +4,103 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gatorhire/backend/db"
+	"github.com/gatorhire/backend/rbac"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // JWT secret key
 var jwtSecret = []byte(getEnvOrDefault("JWT_SECRET", "your-secret-key"))
 
-// Claims defines the JWT claims
+// Claims defines the JWT claims. The registered ID claim (jti) identifies the
+// token for revocation; SessionID ties it to the refresh token family it was
+// issued from, if any. MFA is set once the session passed two-factor
+// authentication. Grants lists the user's company and hiring team roles when
+// the token was issued, for clients to tailor what they show; the server
+// checks memberships afresh, so removing one takes effect immediately.
+//
+// Requests made with an API key get claims too, which never become a token:
+// APIKeyID names the key and Scopes are the only permissions it may use.
 type Claims struct {
-	UserID string `json:"userId"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    string            `json:"userId"`
+	Email     string            `json:"email"`
+	Role      string            `json:"role"`
+	SessionID string            `json:"sid,omitempty"`
+	MFA       bool              `json:"mfa,omitempty"`
+	Grants    []rbac.Grant      `json:"grants,omitempty"`
+	APIKeyID  string            `json:"-"`
+	Scopes    []rbac.Permission `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// ScopeAllows reports whether the credential may be used for a permission:
+// sessions may use any the user holds, API keys only those in their scopes
+func (c *Claims) ScopeAllows(permission rbac.Permission) bool {
+	if c.APIKeyID == "" {
+		return true
+	}
+	for _, scope := range c.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminTwoFactorRequired reports whether REQUIRE_ADMIN_2FA makes admin
+// accounts pass two-factor authentication before they can do anything
+func AdminTwoFactorRequired() bool {
+	required, _ := strconv.ParseBool(os.Getenv("REQUIRE_ADMIN_2FA"))
+	return required
+}
+
+// defaultAccessTokenTTL is how long an access token is valid, unless
+// ACCESS_TOKEN_TTL_MINUTES says otherwise. Clients renew it with their
+// refresh token.
+const defaultAccessTokenTTL = 15 * time.Minute
+
+// AccessTokenTTL returns the configured lifetime of access tokens
+func AccessTokenTTL() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultAccessTokenTTL
+}
+
 // GenerateToken generates a JWT token for a user
 func GenerateToken(userID, email, role string) (string, error) {
-	// Set expiration time
-	expirationTime := time.Now().Add(24 * time.Hour)
-	
+	tokenString, _, err := GenerateSessionToken(Claims{UserID: userID, Email: email, Role: role})
+	return tokenString, err
+}
+
+// GenerateSessionToken generates an access token for the user, session and
+// grants in claims, and returns its complete claims alongside it. Any
+// registered claims passed in are replaced.
+func GenerateSessionToken(session Claims) (string, *Claims, error) {
+	now := time.Now()
+
 	// Create claims
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "gatorhire",
-		},
+	claims := &session
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    "gatorhire",
 	}
-	
-	// Create token
+
+	// Create and sign token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	
-	// Sign token
 	tokenString, err := token.SignedString(jwtSecret)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	
-	return tokenString, nil
+
+	return tokenString, claims, nil
 }
 
 // ValidateToken validates a JWT token
@@ -75,27 +128,68 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// TokenRevoked reports whether an access token was revoked, either by its own
+// ID or because its refresh token family was. Tokens without an ID predate
+// revocation and count as revoked.
+func TokenRevoked(claims *Claims) (bool, error) {
+	if claims.ID == "" {
+		return true, nil
+	}
+
+	var revoked bool
+	err := db.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)
+			OR EXISTS(SELECT 1 FROM refresh_tokens WHERE family_id = $2 AND revoked_at IS NOT NULL)
+	`, claims.ID, claims.SessionID).Scan(&revoked)
+	return revoked, err
+}
+
 // GetUserFromToken extracts user ID and role from the Authorization header
 func GetUserFromToken(r *http.Request) (string, string, error) {
+	claims, err := GetClaimsFromToken(r)
+	if err != nil {
+		return "", "", err
+	}
+
+	return claims.UserID, claims.Role, nil
+}
+
+// GetClaimsFromToken returns the claims AuthMiddleware authenticated the
+// request with, or else validates the Bearer token in the Authorization
+// header and returns its claims. Revoked tokens are refused here too, so
+// public routes treat them as no token at all.
+func GetClaimsFromToken(r *http.Request) (*Claims, error) {
+	if claims, ok := r.Context().Value("claims").(*Claims); ok {
+		return claims, nil
+	}
+
 	// Get Authorization header
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		return "", "", errors.New("authorization header is required")
+		return nil, errors.New("authorization header is required")
 	}
-	
+
 	// Extract token
 	tokenParts := strings.Split(authHeader, " ")
 	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-		return "", "", errors.New("invalid authorization header format")
+		return nil, errors.New("invalid authorization header format")
 	}
-	
+
 	// Validate token
 	claims, err := ValidateToken(tokenParts[1])
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
-	
-	return claims.UserID, claims.Role, nil
+
+	// Reject tokens revoked by logout or by refresh token reuse
+	revoked, err := TokenRevoked(claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("token has been revoked")
+	}
+	return claims, nil
 }
 
 // Helper function to get environment variable with default value
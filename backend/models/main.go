@@ -5,10 +5,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gatorhire/backend/db"
 	"github.com/gatorhire/backend/handlers"
+	"github.com/gatorhire/backend/mailer"
 	"github.com/gatorhire/backend/middleware"
+	"github.com/gatorhire/backend/rbac"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 )
@@ -18,6 +21,28 @@ func main() {
 	db.InitDB()
 	defer db.CloseDB()
 
+	// Deliver emails as configured by MAILER (SMTP, files or the console)
+	handlers.SetMailer(mailer.FromEnv())
+
+	// Match newly published jobs against saved searches and email the alerts
+	// that are due in the background
+	handlers.StartAlertMatcher(5 * time.Minute)
+
+	// Write job funnel events to the analytics rollups off the request path
+	handlers.StartAnalyticsWriter()
+
+	// Keep the in-memory recommendation index in sync with published jobs
+	handlers.StartRecommendationIndexer(10 * time.Minute)
+
+	// Extract skills for jobs posted before the skills taxonomy existed
+	handlers.StartJobSkillBackfill()
+
+	// Clear out application drafts of jobs that have closed
+	handlers.StartApplicationDraftExpiry(time.Hour)
+
+	// Forget refresh tokens and revocations once they have expired
+	handlers.StartSessionCleanup(time.Hour)
+
 	// Create router
 	r := mux.NewRouter()
 
@@ -28,39 +53,133 @@ func main() {
 	api.HandleFunc("/jobs", handlers.GetJobs).Methods("GET", "OPTIONS")
 	api.HandleFunc("/jobs/{id}", handlers.GetJobByID).Methods("GET", "OPTIONS")
 	api.HandleFunc("/jobs/search", handlers.SearchJobs).Methods("GET", "OPTIONS") // New endpoint
-	api.HandleFunc("/auth/login", handlers.Login).Methods("POST", "OPTIONS")
+	api.HandleFunc("/jobs/facets/work-arrangement", handlers.GetWorkArrangementFacets).Methods("GET", "OPTIONS")
+	api.HandleFunc("/jobs/{id}/similar", handlers.GetSimilarJobs).Methods("GET", "OPTIONS")
+	api.HandleFunc("/jobs/{id}/skills", handlers.GetJobSkills).Methods("GET", "OPTIONS")
+	api.HandleFunc("/skills", handlers.GetSkills).Methods("GET", "OPTIONS")
+	api.HandleFunc("/jobs/{id}/jsonld", handlers.GetJobPostingJSONLD).Methods("GET", "OPTIONS")
+	api.HandleFunc("/jobs/{id}/views", handlers.RecordJobView).Methods("POST", "OPTIONS")
+	api.HandleFunc("/feeds/jobs.rss", handlers.GetJobsRSS).Methods("GET", "OPTIONS")
+	api.HandleFunc("/feeds/jobs.atom", handlers.GetJobsAtom).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/login", handlers.ProtectLogin(handlers.Login)).Methods("POST", "OPTIONS")
 	api.HandleFunc("/auth/register", handlers.Register).Methods("POST", "OPTIONS")
-	api.HandleFunc("/applications", handlers.CreateApplication).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/refresh", handlers.RefreshSession).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/logout", handlers.Logout).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/password-reset", handlers.RequestPasswordReset).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/password-reset/confirm", handlers.ConfirmPasswordReset).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/verify-email", handlers.VerifyEmail).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/2fa/verify", handlers.VerifyTwoFactorLogin).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/oidc/providers", handlers.GetSSOProviders).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/oidc/exchange", handlers.CompleteSSOLogin).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/oidc/{provider}/login", handlers.StartSSOLogin).Methods("GET", "OPTIONS")
+	api.HandleFunc("/auth/oidc/{provider}/callback", handlers.SSOCallback).Methods("GET", "OPTIONS")
+	api.HandleFunc("/alerts/unsubscribe", handlers.UnsubscribeSavedSearch).Methods("GET", "OPTIONS")
+	api.HandleFunc("/alerts/unsubscribe", handlers.DeleteSavedSearchByToken).Methods("POST", "OPTIONS")
+	api.HandleFunc("/companies", handlers.GetCompanies).Methods("GET", "OPTIONS")
+	api.HandleFunc("/companies/{id}", handlers.GetCompany).Methods("GET", "OPTIONS")
+
+	// Two-factor routes (require JWT token; also open to admins who still
+	// have to pass two-factor authentication)
+	twoFactorAPI := api.PathPrefix("/auth/2fa").Subrouter()
+	twoFactorAPI.Use(middleware.TwoFactorSetupMiddleware)
+
+	twoFactorAPI.HandleFunc("", handlers.GetTwoFactorStatus).Methods("GET", "OPTIONS")
+	twoFactorAPI.HandleFunc("/setup", handlers.SetupTwoFactor).Methods("POST", "OPTIONS")
+	twoFactorAPI.HandleFunc("/enable", handlers.EnableTwoFactor).Methods("POST", "OPTIONS")
+	twoFactorAPI.HandleFunc("/disable", handlers.DisableTwoFactor).Methods("POST", "OPTIONS")
+	twoFactorAPI.HandleFunc("/recovery-codes", handlers.RegenerateRecoveryCodes).Methods("POST", "OPTIONS")
+	twoFactorAPI.HandleFunc("/step-up", handlers.StepUpTwoFactor).Methods("POST", "OPTIONS")
 
-	// Authenticated routes (require JWT token)
+	// Authenticated routes for the user's own account (require a JWT token;
+	// API keys are refused)
 	authAPI := api.PathPrefix("").Subrouter()
-	authAPI.Use(middleware.AuthMiddleware)
+	authAPI.Use(middleware.SessionMiddleware)
 
+	authAPI.HandleFunc("/applications", handlers.CreateApplication).Methods("POST", "OPTIONS")
 	authAPI.HandleFunc("/applications/user", handlers.GetUserApplications).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/auth/verify-email/resend", handlers.ResendVerificationEmail).Methods("POST", "OPTIONS")
+	authAPI.HandleFunc("/student-verification", handlers.RequestStudentVerification).Methods("POST", "OPTIONS")
+	authAPI.HandleFunc("/auth/oidc/{provider}/link", handlers.LinkSSOAccount).Methods("POST", "OPTIONS")
 	authAPI.HandleFunc("/saved-jobs", handlers.SaveJob).Methods("POST", "OPTIONS")
 	authAPI.HandleFunc("/saved-jobs", handlers.UnsaveJob).Methods("DELETE", "OPTIONS")
 	authAPI.HandleFunc("/saved-jobs/bulk", handlers.BulkDeleteSavedJobs).Methods("DELETE", "OPTIONS") // New endpoint
 	authAPI.HandleFunc("/saved-jobs", handlers.GetSavedJobs).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/dashboard", handlers.GetCandidateDashboard).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/application-drafts", handlers.GetApplicationDrafts).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/jobs/{id}/application-draft", handlers.GetApplicationDraft).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/jobs/{id}/application-draft", handlers.SaveApplicationDraft).Methods("PUT", "OPTIONS")
+	authAPI.HandleFunc("/jobs/{id}/application-draft", handlers.DeleteApplicationDraft).Methods("DELETE", "OPTIONS")
+	authAPI.HandleFunc("/jobs/{id}/application-draft/submit", handlers.SubmitApplicationDraft).Methods("POST", "OPTIONS")
 	authAPI.HandleFunc("/profile", handlers.GetProfile).Methods("GET", "OPTIONS")
 	authAPI.HandleFunc("/profile", handlers.UpdateProfile).Methods("PUT", "OPTIONS")
+	authAPI.HandleFunc("/profile/work-preference", handlers.SetWorkPreference).Methods("PUT", "OPTIONS")
+	authAPI.HandleFunc("/profile/skills", handlers.SetProfileSkills).Methods("PUT", "OPTIONS")
 	authAPI.HandleFunc("/profile/stats", handlers.GetProfileStats).Methods("GET", "OPTIONS")              // New endpoint
 	authAPI.HandleFunc("/jobs/recommendations", handlers.GetJobRecommendations).Methods("GET", "OPTIONS") // New endpoint
+	authAPI.HandleFunc("/jobs/{id}/dismiss", handlers.DismissJob).Methods("POST", "OPTIONS")
+	authAPI.HandleFunc("/jobs/{id}/dismiss", handlers.UndismissJob).Methods("DELETE", "OPTIONS")
+	authAPI.HandleFunc("/submissions", handlers.GetMySubmissions).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/alerts", handlers.GetSavedSearches).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/alerts", handlers.CreateSavedSearch).Methods("POST", "OPTIONS")
+	authAPI.HandleFunc("/alerts/{id}", handlers.UpdateSavedSearch).Methods("PUT", "OPTIONS")
+	authAPI.HandleFunc("/alerts/{id}", handlers.DeleteSavedSearch).Methods("DELETE", "OPTIONS")
+	authAPI.HandleFunc("/alerts/{id}/mute", handlers.MuteSavedSearch).Methods("PUT", "OPTIONS")
+	authAPI.HandleFunc("/api-keys", handlers.GetAPIKeys).Methods("GET", "OPTIONS")
+	authAPI.HandleFunc("/api-keys", handlers.CreateAPIKey).Methods("POST", "OPTIONS")
+	authAPI.HandleFunc("/api-keys/{id}", handlers.RevokeAPIKey).Methods("DELETE", "OPTIONS")
+
+	// Staff routes (require a JWT token or an API key, and a permission held
+	// site-wide or for the job or company the route acts on). The routes
+	// without RequirePermission check it in the handler.
+	staffAPI := api.PathPrefix("").Subrouter()
+	staffAPI.Use(middleware.AuthMiddleware)
+	requires := func(permission rbac.Permission, scope middleware.ScopeFunc, handler http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(permission, scope)(handler)
+	}
+	job := middleware.JobFromVar("id")
 
-	// Admin routes (require JWT token and admin role)
-	adminAPI := api.PathPrefix("").Subrouter()
-	adminAPI.Use(middleware.AuthMiddleware, middleware.AdminMiddleware)
+	staffAPI.HandleFunc("/submissions", handlers.SubmitJob).Methods("POST", "OPTIONS")
+	staffAPI.HandleFunc("/submissions/{id}", handlers.ResubmitJob).Methods("PUT", "OPTIONS")
+	staffAPI.HandleFunc("/jobs/{id}/clone", handlers.CloneJob).Methods("POST", "OPTIONS")
+	staffAPI.HandleFunc("/companies/{id}/templates", handlers.GetCompanyTemplates).Methods("GET", "OPTIONS")
+	staffAPI.HandleFunc("/companies/{id}/templates", handlers.CreateCompanyTemplate).Methods("POST", "OPTIONS")
+	staffAPI.HandleFunc("/templates/{id}", handlers.UpdateJobTemplate).Methods("PUT", "OPTIONS")
+	staffAPI.HandleFunc("/templates/{id}", handlers.DeleteJobTemplate).Methods("DELETE", "OPTIONS")
+	staffAPI.HandleFunc("/templates/{id}/jobs", handlers.InstantiateJobTemplate).Methods("POST", "OPTIONS")
+	staffAPI.HandleFunc("/companies/{id}", handlers.UpdateCompany).Methods("PUT", "OPTIONS")
+	staffAPI.HandleFunc("/companies/{id}/members", handlers.GetCompanyMembers).Methods("GET", "OPTIONS")
+	staffAPI.HandleFunc("/companies/{id}/members", handlers.AddCompanyMember).Methods("POST", "OPTIONS")
+	staffAPI.HandleFunc("/companies/{id}/members/{userId}", handlers.RemoveCompanyMember).Methods("DELETE", "OPTIONS")
+	staffAPI.HandleFunc("/analytics/jobs/{id}", handlers.GetJobAnalytics).Methods("GET", "OPTIONS")
+	staffAPI.HandleFunc("/analytics/companies/{id}", handlers.GetCompanyAnalytics).Methods("GET", "OPTIONS")
 
-	adminAPI.HandleFunc("/jobs", handlers.CreateJob).Methods("POST", "OPTIONS")
-	adminAPI.HandleFunc("/jobs/{id}", handlers.UpdateJob).Methods("PUT", "OPTIONS")
-	adminAPI.HandleFunc("/jobs/{id}", handlers.DeleteJob).Methods("DELETE", "OPTIONS")
-	adminAPI.HandleFunc("/applications/job", handlers.GetApplicationsByJob).Methods("GET", "OPTIONS")
-	adminAPI.HandleFunc("/applications/status", handlers.UpdateApplicationStatus).Methods("PUT", "OPTIONS")
+	staffAPI.Handle("/jobs", requires(rbac.JobsCreate, nil, handlers.CreateJob)).Methods("POST", "OPTIONS")
+	staffAPI.Handle("/jobs/import", requires(rbac.JobsImport, nil, handlers.ImportJobs)).Methods("POST", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}", requires(rbac.JobsUpdate, job, handlers.UpdateJob)).Methods("PUT", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}", requires(rbac.JobsDelete, job, handlers.DeleteJob)).Methods("DELETE", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}/restore", requires(rbac.JobsDelete, job, handlers.RestoreJob)).Methods("POST", "OPTIONS")
+	staffAPI.Handle("/archive/jobs", requires(rbac.JobsArchive, nil, handlers.GetArchivedJobs)).Methods("GET", "OPTIONS")
+	staffAPI.Handle("/archive/jobs/purge", requires(rbac.JobsArchive, nil, handlers.PurgeArchivedJobs)).Methods("POST", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}/revisions", requires(rbac.JobsRead, job, handlers.GetJobRevisions)).Methods("GET", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}/revisions/diff", requires(rbac.JobsRead, job, handlers.GetJobRevisionDiff)).Methods("GET", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}/team", requires(rbac.JobsRead, job, handlers.GetHiringTeam)).Methods("GET", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}/team", requires(rbac.JobsTeam, job, handlers.AddHiringTeamMember)).Methods("POST", "OPTIONS")
+	staffAPI.Handle("/jobs/{id}/team/{userId}", requires(rbac.JobsTeam, job, handlers.RemoveHiringTeamMember)).Methods("DELETE", "OPTIONS")
+	staffAPI.Handle("/applications/job", requires(rbac.ApplicationsRead, middleware.JobFromQuery("jobId"), handlers.GetApplicationsByJob)).Methods("GET", "OPTIONS")
+	staffAPI.Handle("/applications/export", requires(rbac.ApplicationsExport, middleware.JobFromQuery("jobId"), handlers.ExportApplications)).Methods("GET", "OPTIONS")
+	staffAPI.HandleFunc("/applications/status", handlers.UpdateApplicationStatus).Methods("PUT", "OPTIONS")
+	staffAPI.Handle("/companies", requires(rbac.CompaniesCreate, nil, handlers.CreateCompany)).Methods("POST", "OPTIONS")
+	staffAPI.Handle("/skills", requires(rbac.SkillsManage, nil, handlers.CreateSkill)).Methods("POST", "OPTIONS")
+	staffAPI.Handle("/moderation/jobs", requires(rbac.ModerationReview, nil, handlers.GetModerationQueue)).Methods("GET", "OPTIONS")
+	staffAPI.Handle("/moderation/jobs/{id}", requires(rbac.ModerationReview, nil, handlers.ModerateJob)).Methods("PUT", "OPTIONS")
+	staffAPI.Handle("/users/{id}/role", requires(rbac.UsersManage, nil, handlers.SetUserRole)).Methods("PUT", "OPTIONS")
+	staffAPI.Handle("/users/{id}/unlock", requires(rbac.UsersManage, nil, handlers.UnlockLogin)).Methods("POST", "OPTIONS")
 
 	// Set up CORS
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Session-ID"},
 		AllowCredentials: true,
 	})
 
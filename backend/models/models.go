@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"github.com/gatorhire/backend/formschema"
 )
 
 // User represents a user in the system
@@ -19,6 +21,15 @@ type User struct {
 	Skills    *json.RawMessage `json:"skills"`             // Changed to *json.RawMessage
 	Role      string           `json:"role,omitempty"`
 	CreatedAt time.Time        `json:"createdAt"`
+
+	// EmailVerified is set once the user opened the link emailed to them
+	EmailVerified bool `json:"emailVerified"`
+	// VerifiedStudent is set once the user proved they own an address on a trusted university domain
+	VerifiedStudent bool `json:"verifiedStudent"`
+
+	// WorkPreference is the candidate's preferred work arrangement
+	// ("remote", "hybrid" or "onsite"); nil means no preference
+	WorkPreference *string `json:"workPreference,omitempty"`
 }
 
 // Job represents a job posting
@@ -38,6 +49,100 @@ type Job struct {
 	Status           string       `json:"status"`
 	CompanyInfo      *CompanyInfo `json:"companyInfo,omitempty"`
 	CreatedBy        string       `json:"createdBy,omitempty"`
+
+	// WorkArrangement is one of the WorkArrangement* constants
+	WorkArrangement string `json:"workArrangement"`
+	// RemoteRegions lists the regions a remote/hybrid hire may work from (e.g. "US", "EU"); empty means anywhere
+	RemoteRegions []string `json:"remoteRegions,omitempty"`
+	// RemoteTimeZones lists the time zones a remote/hybrid hire must overlap with (e.g. "America/New_York")
+	RemoteTimeZones []string `json:"remoteTimeZones,omitempty"`
+	// ExternalID is the employer's own reference for the posting, used to match re-imports
+	ExternalID string `json:"externalId,omitempty"`
+	// CompanyID links the job to its company profile, which is the source of Company and CompanyInfo
+	CompanyID string `json:"companyId,omitempty"`
+	// ModerationStatus is one of the Moderation* constants; only approved jobs are public
+	ModerationStatus string `json:"moderationStatus,omitempty"`
+	// ArchivedAt is set when the job was deleted; archived jobs are hidden but keep their history
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+	// Screening lists what applicants must provide; nil means nothing beyond the basics
+	Screening *ScreeningSettings `json:"screening,omitempty"`
+	// ApplicationForm is a JSON Schema of extra questions applicants answer (see package formschema)
+	ApplicationForm json.RawMessage `json:"applicationForm,omitempty"`
+	// StudentsOnly restricts the job to verified students: others can't see it or apply
+	StudentsOnly bool `json:"studentsOnly,omitempty"`
+}
+
+// ScreeningSettings are the application requirements of a job
+type ScreeningSettings struct {
+	RequireResume      bool `json:"requireResume"`
+	RequireCoverLetter bool `json:"requireCoverLetter"`
+	RequirePortfolio   bool `json:"requirePortfolio"`
+}
+
+// JobTemplate is a reusable starting point for a company's postings. Text
+// fields may contain variables such as {{semester}}, filled in when a job is
+// created from the template.
+type JobTemplate struct {
+	ID               string             `json:"id"`
+	CompanyID        string             `json:"companyId"`
+	Name             string             `json:"name"`
+	Title            string             `json:"title"`
+	Location         string             `json:"location"`
+	Type             string             `json:"type"`
+	Salary           string             `json:"salary"`
+	Category         string             `json:"category"`
+	Description      string             `json:"description"`
+	Requirements     []string           `json:"requirements"`
+	Responsibilities []string           `json:"responsibilities,omitempty"`
+	Benefits         []string           `json:"benefits,omitempty"`
+	WorkArrangement  string             `json:"workArrangement,omitempty"`
+	Screening        *ScreeningSettings `json:"screening,omitempty"`
+	CreatedBy        string             `json:"createdBy,omitempty"`
+	CreatedAt        time.Time          `json:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt"`
+}
+
+// Work arrangements supported for job postings and candidate preferences
+const (
+	WorkArrangementRemote = "remote"
+	WorkArrangementHybrid = "hybrid"
+	WorkArrangementOnsite = "onsite"
+)
+
+// Moderation states of a job posting
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// ModerationFlag is an automated check a submitted posting tripped
+type ModerationFlag struct {
+	Code    string `json:"code"` // "missing_salary", "off_platform_payment" or "duplicate_text"
+	Message string `json:"message"`
+}
+
+// ModerationItem is a submitted posting together with its review state
+type ModerationItem struct {
+	Job
+	Flags       []ModerationFlag `json:"flags"`
+	Reason      string           `json:"reason,omitempty"` // why it was rejected, shown to the submitter
+	ModeratedBy string           `json:"moderatedBy,omitempty"`
+	ModeratedAt *time.Time       `json:"moderatedAt,omitempty"`
+}
+
+// ModerationDecision is a moderator's verdict on a submitted posting
+type ModerationDecision struct {
+	Decision string `json:"decision"` // "approve" or "reject"
+	Reason   string `json:"reason"`   // required when rejecting
+}
+
+// PurgeReport is the result of purging archived jobs
+type PurgeReport struct {
+	DryRun        bool     `json:"dryRun"`
+	ArchivedUntil string   `json:"archivedUntil"` // jobs archived before this date were purged
+	Purged        int      `json:"purged"`
+	JobIDs        []string `json:"jobIds"`
 }
 
 // CompanyInfo represents information about a company
@@ -49,6 +154,40 @@ type CompanyInfo struct {
 	Size        string `json:"size,omitempty"`
 }
 
+// Company is a hiring organization's profile, shared by all of its jobs
+type Company struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	LogoURL     string    `json:"logoUrl,omitempty"`
+	Website     string    `json:"website,omitempty"`
+	Industry    string    `json:"industry,omitempty"`
+	Size        string    `json:"size,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	OpenJobs    []Job     `json:"openJobs,omitempty"`
+}
+
+// CompanyMember links a recruiter account to a company
+type CompanyMember struct {
+	CompanyID string    `json:"companyId"`
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email,omitempty"`
+	FullName  string    `json:"fullName,omitempty"`
+	Role      string    `json:"role"` // "owner", "recruiter", "hiring_manager" or "interviewer"
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// HiringTeamMember gives an account a role on a single job
+type HiringTeamMember struct {
+	JobID     string    `json:"jobId"`
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email,omitempty"`
+	FullName  string    `json:"fullName,omitempty"`
+	Role      string    `json:"role"` // "recruiter", "hiring_manager" or "interviewer"
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 // Value implements the driver.Valuer interface for CompanyInfo
 func (c CompanyInfo) Value() (driver.Value, error) {
 	return json.Marshal(c)
@@ -78,6 +217,49 @@ type Application struct {
 	HeardFrom   string    `json:"heardFrom,omitempty"`
 	CreatedAt   time.Time `json:"createdAt"`
 	Status      string    `json:"status,omitempty"`
+
+	// JobRevisionID is the job revision that was live when the application was submitted
+	JobRevisionID string `json:"jobRevisionId,omitempty"`
+	// Answers holds the applicant's answers to the job's application form, keyed by field
+	Answers map[string]interface{} `json:"answers,omitempty"`
+	// RenderedAnswers lists the answers with their question labels, for reviewers
+	RenderedAnswers []formschema.Answer `json:"renderedAnswers,omitempty"`
+}
+
+// ImportRowResult reports what happened to one row of a job import
+type ImportRowResult struct {
+	Row        int      `json:"row"`
+	ExternalID string   `json:"externalId,omitempty"`
+	JobID      string   `json:"jobId,omitempty"`
+	Action     string   `json:"action"` // "create", "update" or "error"
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// ImportReport summarises a bulk job import
+type ImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// JobRevision is an immutable snapshot of a job taken every time it changes
+type JobRevision struct {
+	ID        string    `json:"id"`
+	JobID     string    `json:"jobId"`
+	Revision  int       `json:"revision"`
+	Snapshot  Job       `json:"snapshot"`
+	ChangedBy string    `json:"changedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// JobFieldChange describes one field that differs between two job revisions
+type JobFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
 }
 
 // SavedJob represents a job saved by a user
@@ -86,6 +268,99 @@ type SavedJob struct {
 	UserID    string    `json:"userId"`
 	JobID     string    `json:"jobId"`
 	SavedDate time.Time `json:"savedDate"`
+	Job       *Job      `json:"job,omitempty"`
+}
+
+// ApplicationDraft is a partly filled application a candidate saved to finish later.
+// Drafts expire once their job closes.
+type ApplicationDraft struct {
+	ID          string      `json:"id"`
+	JobID       string      `json:"jobId"`
+	JobTitle    string      `json:"jobTitle"`
+	Company     string      `json:"company"`
+	Application Application `json:"application"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// CandidateDashboard gathers what a candidate is working on
+type CandidateDashboard struct {
+	SavedJobs         []SavedJob         `json:"savedJobs"`
+	ApplicationDrafts []ApplicationDraft `json:"applicationDrafts"`
+}
+
+// SavedSearch is a job search a user is alerted about when new matching jobs are published
+type SavedSearch struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"userId"`
+	Name           string    `json:"name"`
+	Keywords       string    `json:"keywords,omitempty"`
+	Category       string    `json:"category,omitempty"`
+	Type           string    `json:"type,omitempty"`
+	Location       string    `json:"location,omitempty"`
+	MinSalary      int       `json:"minSalary,omitempty"` // yearly, in USD
+	Frequency      string    `json:"frequency"`           // "instant", "daily" or "weekly"
+	Muted          bool      `json:"muted"`
+	UnsubscribeURL string    `json:"unsubscribeUrl,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	LastMatchedAt  time.Time `json:"-"`
+}
+
+// AlertNotification is a queued notification that a job matched a saved search
+type AlertNotification struct {
+	ID            string     `json:"id"`
+	SavedSearchID string     `json:"savedSearchId"`
+	UserID        string     `json:"userId"`
+	JobID         string     `json:"jobId"`
+	DeliverAt     time.Time  `json:"deliverAt"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// Skill is an entry in the canonical skills taxonomy
+type Skill struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Aliases  []string `json:"aliases,omitempty"` // other spellings, e.g. "golang" for Go
+}
+
+// JobRecommendation is a job suggested to a candidate, with the reason it was picked
+type JobRecommendation struct {
+	Job
+	Score         float64  `json:"score"`
+	Explanation   string   `json:"explanation"`             // e.g. "Because you know Go and SQL"
+	MatchedSkills []string `json:"matchedSkills,omitempty"` // the candidate's skills the job asks for
+}
+
+// SimilarJob is a posting related to the one being viewed
+type SimilarJob struct {
+	Job
+	Score     float64  `json:"score"`
+	MatchedOn []string `json:"matchedOn"` // "title", "requirements", "category" and/or "location"
+}
+
+// JobDailyStats is one day of a job's view → save → application funnel
+type JobDailyStats struct {
+	Date         string `json:"date"`
+	Views        int    `json:"views"`
+	Saves        int    `json:"saves"`
+	Applications int    `json:"applications"`
+}
+
+// FunnelAnalytics summarises a funnel over a date range
+type FunnelAnalytics struct {
+	JobID        string          `json:"jobId,omitempty"`
+	CompanyID    string          `json:"companyId,omitempty"`
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Views        int             `json:"views"`
+	Saves        int             `json:"saves"`
+	Applications int             `json:"applications"`
+	ViewToSave   float64         `json:"viewToSave"`
+	ViewToApply  float64         `json:"viewToApply"`
+	SaveToApply  float64         `json:"saveToApply"`
+	Daily        []JobDailyStats `json:"daily"`
 }
 
 // AuthResponse represents the response for authentication endpoints
@@ -93,6 +368,70 @@ type AuthResponse struct {
 	Success bool   `json:"success"`
 	User    User   `json:"user"`
 	Token   string `json:"token"`
+
+	// RefreshToken renews the session via /api/auth/refresh; each one works once
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// ExpiresIn is the lifetime of Token in seconds
+	ExpiresIn int `json:"expiresIn,omitempty"`
+
+	// TwoFactorRequired means the password was right but the account uses
+	// two-factor authentication: no tokens are issued until ChallengeToken
+	// and a code are posted to /api/auth/2fa/verify
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	ChallengeToken    string `json:"challengeToken,omitempty"`
+}
+
+// SSOProvider is an identity provider users can log in with
+type SSOProvider struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	LoginURL string `json:"loginUrl"` // send the browser here to log in
+}
+
+// SSOAuthorization is the identity provider URL to send the browser to
+type SSOAuthorization struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+// TwoFactorStatus describes the current user's two-factor authentication
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+// TwoFactorSetup is a new TOTP secret to add to an authenticator app, also
+// as an otpauth:// URI to show as a QR code
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// TwoFactorResponse answers two-factor requests: Token is a step-up access
+// token, RecoveryCodes are shown to the user once
+type TwoFactorResponse struct {
+	Success       bool     `json:"success"`
+	Token         string   `json:"token,omitempty"`
+	ExpiresIn     int      `json:"expiresIn,omitempty"`
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// APIKey is a personal API key as its owner sees it; the key itself is only
+// shown once, in CreatedAPIKey
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedAPIKey answers the creation of an API key
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
 }
 
 // ErrorResponse represents an error response
@@ -100,11 +439,24 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// ValidationErrorResponse is an error response that points at the offending fields
+type ValidationErrorResponse struct {
+	Error  string                  `json:"error"`
+	Fields []formschema.FieldError `json:"fields"`
+}
+
 // SuccessResponse represents a generic success response
 type SuccessResponse struct {
 	Success bool `json:"success"`
 }
 
+// StudentVerificationResponse says whether a student verification request
+// took effect at once, or a confirmation link was emailed first
+type StudentVerificationResponse struct {
+	Success         bool `json:"success"`
+	VerifiedStudent bool `json:"verifiedStudent"`
+}
+
 // Initialize mock data slices
 var (
 	// Jobs is a slice of Job for easier handling of collections
@@ -0,0 +1,396 @@
+// Package oidc is an OpenID Connect relying party. It sends users to an
+// identity provider with the authorization code flow and PKCE, exchanges the
+// code that comes back and verifies the provider's ID token.
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config configures one identity provider
+type Config struct {
+	ID           string // short name used in URLs, e.g. "ufl"
+	Name         string // shown on the login button
+	Issuer       string // discovery happens at Issuer + "/.well-known/openid-configuration"
+	ClientID     string
+	ClientSecret string   // empty for public clients, which rely on PKCE alone
+	Scopes       []string // "openid" is always requested
+	// TrustEmail treats every email the provider returns as verified, for
+	// providers that don't send email_verified but only issue addresses they own
+	TrustEmail bool
+}
+
+// ConfigsFromEnv reads the providers named in the comma-separated
+// OIDC_PROVIDERS. Each one is configured with OIDC_<ID>_ISSUER,
+// OIDC_<ID>_CLIENT_ID, OIDC_<ID>_CLIENT_SECRET and optionally
+// OIDC_<ID>_NAME, OIDC_<ID>_SCOPES (space-separated) and
+// OIDC_<ID>_TRUST_EMAIL. Providers without an issuer or client ID are skipped.
+func ConfigsFromEnv() []Config {
+	var configs []Config
+	for _, id := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+		config := Config{
+			ID:           id,
+			Name:         os.Getenv(prefix + "NAME"),
+			Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		config.TrustEmail, _ = strconv.ParseBool(os.Getenv(prefix + "TRUST_EMAIL"))
+		if config.Issuer == "" || config.ClientID == "" {
+			continue
+		}
+		if config.Name == "" {
+			config.Name = id
+		}
+		if len(config.Scopes) == 0 {
+			config.Scopes = []string{"openid", "email", "profile"}
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// Claims is what the relying party learns about the user from the ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Nonce         string
+}
+
+// discovery is the part of the provider metadata the flow needs
+type discovery struct {
+	Issuer                   string   `json:"issuer"`
+	AuthorizationEndpoint    string   `json:"authorization_endpoint"`
+	TokenEndpoint            string   `json:"token_endpoint"`
+	JWKSURI                  string   `json:"jwks_uri"`
+	TokenEndpointAuthMethods []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// Provider is a discovered identity provider
+type Provider struct {
+	Config
+	metadata discovery
+	client   *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// keyRefreshInterval limits how often an unknown key ID makes the provider's
+// keys be fetched again
+const keyRefreshInterval = time.Minute
+
+// maxResponseSize bounds what is read from the provider
+const maxResponseSize = 1 << 20
+
+// Discover fetches the provider's metadata. A nil client means
+// http.DefaultClient.
+func Discover(ctx context.Context, config Config, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	provider := &Provider{Config: config, client: client}
+	if err := provider.getJSON(ctx, config.Issuer+"/.well-known/openid-configuration", &provider.metadata); err != nil {
+		return nil, fmt.Errorf("discovering %s: %v", config.Issuer, err)
+	}
+	if provider.metadata.Issuer != config.Issuer {
+		return nil, fmt.Errorf("provider reports issuer %q, expected %q", provider.metadata.Issuer, config.Issuer)
+	}
+	if provider.metadata.AuthorizationEndpoint == "" || provider.metadata.TokenEndpoint == "" || provider.metadata.JWKSURI == "" {
+		return nil, errors.New("provider metadata is missing endpoints")
+	}
+	return provider, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// RandomString returns a URL-safe random string, for states, nonces and
+// PKCE code verifiers
+func RandomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CodeChallenge is the S256 PKCE challenge of a code verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL is where to send the user to log in
+func (p *Provider) AuthCodeURL(redirectURI, state, nonce, codeVerifier string) string {
+	scopes := []string{"openid"}
+	for _, scope := range p.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", CodeChallenge(codeVerifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange trades an authorization code for tokens and returns the claims of
+// the verified ID token. Callers must compare Claims.Nonce with the nonce
+// they sent.
+func (p *Provider) Exchange(ctx context.Context, code, redirectURI, codeVerifier string) (*Claims, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.ClientID)
+
+	// client_secret_basic is the default; use the form only if that's all
+	// the provider takes
+	secretInForm := len(p.metadata.TokenEndpointAuthMethods) > 0
+	for _, method := range p.metadata.TokenEndpointAuthMethods {
+		if method == "client_secret_basic" {
+			secretInForm = false
+		}
+	}
+	if p.ClientSecret != "" && secretInForm {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" && !secretInForm {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("token request failed: %s %s %s", resp.Status, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return p.VerifyIDToken(ctx, tokens.IDToken)
+}
+
+// idTokenClaims are the ID token claims checked or used. Some providers send
+// email_verified as a string.
+type idTokenClaims struct {
+	Email           string      `json:"email"`
+	EmailVerified   interface{} `json:"email_verified"`
+	Name            string      `json:"name"`
+	Nonce           string      `json:"nonce"`
+	AuthorizedParty string      `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// VerifyIDToken checks an ID token's signature against the provider's keys
+// and its issuer, audience and expiry
+func (p *Provider) VerifyIDToken(ctx context.Context, raw string) (*Claims, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(p.metadata.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.ClientID {
+		return nil, errors.New("invalid ID token: issued to another client")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid ID token: no subject")
+	}
+
+	verified := p.TrustEmail
+	switch value := claims.EmailVerified.(type) {
+	case bool:
+		verified = verified || value
+	case string:
+		verified = verified || value == "true"
+	}
+	return &Claims{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: verified && claims.Email != "",
+		Name:          claims.Name,
+		Nonce:         claims.Nonce,
+	}, nil
+}
+
+// key returns the provider's signing key with the given ID, fetching the key
+// set again if the ID is new (keys rotate)
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	p.keysFetched = time.Now()
+	if err := p.getJSON(ctx, p.metadata.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %v", err)
+	}
+	p.keys = map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if key, err := jwk.publicKey(); err == nil && (jwk.Use == "" || jwk.Use == "sig") {
+			p.keys[jwk.Kid] = key
+		}
+	}
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID; tokens without an ID can use the only key
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// jsonWebKey is an RSA or EC public key in a JWKS (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	decode := func(value string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,139 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gatorhire/backend/oidc"
+	"github.com/gatorhire/backend/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectURI = "http://localhost:8083/api/auth/oidc/mock/callback"
+
+// login runs the browser's part of the flow: follow the authorization URL
+// and return the code and state the provider redirects back with
+func login(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if !assert.NoError(t, err) {
+		return "", ""
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, _ := url.Parse(resp.Header.Get("Location"))
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+// ✅ Test the authorization code flow with PKCE against the mock provider
+func TestAuthorizationCodeFlow(t *testing.T) {
+	server, mock := oidctest.NewServer("gatorhire", "secret")
+	defer server.Close()
+	mock.SetIdentity(oidctest.Identity{Subject: "1234", Email: "Albert@UFL.edu", EmailVerified: true, Name: "Albert Gator"})
+
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, oidc.Config{ID: "mock", Issuer: server.URL, ClientID: "gatorhire", ClientSecret: "secret"}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	verifier, state, nonce := oidc.RandomString(), oidc.RandomString(), oidc.RandomString()
+	code, returnedState := login(t, provider.AuthCodeURL(redirectURI, state, nonce, verifier))
+	assert.Equal(t, state, returnedState)
+
+	// The code is bound to the PKCE verifier
+	_, err = provider.Exchange(ctx, code, redirectURI, oidc.RandomString())
+	assert.Error(t, err)
+
+	code, _ = login(t, provider.AuthCodeURL(redirectURI, state, nonce, verifier))
+	claims, err := provider.Exchange(ctx, code, redirectURI, verifier)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "1234", claims.Subject)
+	assert.Equal(t, "albert@ufl.edu", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, nonce, claims.Nonce)
+
+	// Codes work once
+	_, err = provider.Exchange(ctx, code, redirectURI, verifier)
+	assert.Error(t, err)
+}
+
+// ✅ Test ID tokens are refused when signed by another key, for another client or expired
+func TestVerifyIDToken(t *testing.T) {
+	server, mock := oidctest.NewServer("gatorhire", "")
+	defer server.Close()
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, oidc.Config{ID: "mock", Issuer: server.URL, ClientID: "gatorhire"}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	identity := oidctest.Identity{Subject: "1234", Email: "albert@ufl.edu"}
+	token, err := mock.IDToken(identity, "", time.Now())
+	assert.NoError(t, err)
+	claims, err := provider.VerifyIDToken(ctx, token)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, claims.EmailVerified)
+
+	expired, _ := mock.IDToken(identity, "", time.Now().Add(-3*time.Hour))
+	_, err = provider.VerifyIDToken(ctx, expired)
+	assert.Error(t, err)
+
+	other := oidctest.New(server.URL, "gatorhire", "")
+	forged, _ := other.IDToken(identity, "", time.Now())
+	_, err = provider.VerifyIDToken(ctx, forged)
+	assert.Error(t, err)
+
+	otherClient, _ := oidctest.New(server.URL, "someone-else", "").IDToken(identity, "", time.Now())
+	_, err = provider.VerifyIDToken(ctx, otherClient)
+	assert.Error(t, err)
+
+	// Providers trusted for email make every address verified
+	trusted, err := oidc.Discover(ctx, oidc.Config{ID: "mock", Issuer: server.URL, ClientID: "gatorhire", TrustEmail: true}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	claims, err = trusted.VerifyIDToken(ctx, token)
+	if assert.NoError(t, err) {
+		assert.True(t, claims.EmailVerified)
+	}
+}
+
+// ✅ Test discovery refuses a provider claiming another issuer
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	server, mock := oidctest.NewServer("gatorhire", "")
+	defer server.Close()
+	mock.Issuer = "https://login.example.com"
+
+	_, err := oidc.Discover(context.Background(), oidc.Config{Issuer: server.URL, ClientID: "gatorhire"}, nil)
+	assert.Error(t, err)
+}
+
+// ✅ Test providers are configured from the environment
+func TestConfigsFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "ufl, broken")
+	t.Setenv("OIDC_UFL_ISSUER", "https://login.ufl.edu/")
+	t.Setenv("OIDC_UFL_CLIENT_ID", "gatorhire")
+	t.Setenv("OIDC_UFL_NAME", "UF GatorLink")
+	t.Setenv("OIDC_UFL_TRUST_EMAIL", "true")
+
+	configs := oidc.ConfigsFromEnv()
+	if !assert.Len(t, configs, 1) {
+		return
+	}
+	assert.Equal(t, "ufl", configs[0].ID)
+	assert.Equal(t, "https://login.ufl.edu", configs[0].Issuer)
+	assert.Equal(t, "UF GatorLink", configs[0].Name)
+	assert.True(t, configs[0].TrustEmail)
+	assert.Equal(t, []string{"openid", "email", "profile"}, configs[0].Scopes)
+}
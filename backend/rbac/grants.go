@@ -0,0 +1,58 @@
+package rbac
+
+import (
+	"database/sql"
+
+	"github.com/gatorhire/backend/db"
+)
+
+// LoadGrants returns the roles a user holds for companies and jobs: their
+// company memberships and their places on hiring teams
+func LoadGrants(userID string) ([]Grant, error) {
+	grants := []Grant{}
+
+	rows, err := db.DB.Query(`
+		SELECT role, company_id, '' FROM company_members WHERE user_id = $1
+		UNION ALL
+		SELECT role, '', job_id FROM job_members WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var grant Grant
+		if err := rows.Scan(&grant.Role, &grant.CompanyID, &grant.JobID); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	return grants, rows.Err()
+}
+
+// JobScope returns the scope of a job. For a job that does not exist it
+// returns sql.ErrNoRows together with a scope of the ID alone, which only
+// site-wide grants apply to, so callers can refuse before saying whether
+// the job exists.
+func JobScope(jobID string) (Scope, error) {
+	var companyID, createdBy sql.NullString
+	err := db.DB.QueryRow("SELECT company_id, created_by FROM jobs WHERE id = $1", jobID).Scan(&companyID, &createdBy)
+	return Scope{CompanyID: companyID.String, JobID: jobID, CreatedBy: createdBy.String}, err
+}
+
+// Authorize reports whether a user holds a permission in scope. Site-wide
+// permissions are decided by the role alone, without a database query.
+func Authorize(userID, role string, permission Permission, scope Scope) (bool, error) {
+	if RoleHas(role, permission) {
+		return true, nil
+	}
+	if scope == (Scope{}) {
+		return false, nil
+	}
+	grants, err := LoadGrants(userID)
+	if err != nil {
+		return false, err
+	}
+	return Allowed(WithCreator(grants, userID, scope), permission, scope), nil
+}
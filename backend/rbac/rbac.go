@@ -0,0 +1,182 @@
+// Package rbac decides what a user may do. Permissions come from roles, and
+// roles are granted either site-wide (the account's role), for a company
+// (company membership) or for a single job (the job's hiring team). Whoever
+// created a job is a recruiter for it.
+package rbac
+
+// Permission names an action, as resource:action
+type Permission string
+
+const (
+	JobsCreate               Permission = "jobs:create"
+	JobsImport               Permission = "jobs:import"
+	JobsRead                 Permission = "jobs:read"
+	JobsUpdate               Permission = "jobs:update"
+	JobsDelete               Permission = "jobs:delete"
+	JobsArchive              Permission = "jobs:archive"
+	JobsTeam                 Permission = "jobs:team"
+	ApplicationsRead         Permission = "applications:read"
+	ApplicationsExport       Permission = "applications:export"
+	ApplicationsUpdateStatus Permission = "applications:update-status"
+	AnalyticsRead            Permission = "analytics:read"
+	CompaniesCreate          Permission = "companies:create"
+	CompanyRead              Permission = "company:read"
+	CompanyUpdate            Permission = "company:update"
+	CompanyMembers           Permission = "company:members"
+	SkillsManage             Permission = "skills:manage"
+	ModerationReview         Permission = "moderation:review"
+	UsersManage              Permission = "users:manage"
+)
+
+// Permissions lists every permission, for validating API key scopes
+var Permissions = []Permission{
+	JobsCreate, JobsImport, JobsRead, JobsUpdate, JobsDelete, JobsArchive, JobsTeam,
+	ApplicationsRead, ApplicationsExport, ApplicationsUpdateStatus, AnalyticsRead,
+	CompaniesCreate, CompanyRead, CompanyUpdate, CompanyMembers,
+	SkillsManage, ModerationReview, UsersManage,
+}
+
+// Site-wide roles, stored on the account
+const (
+	RoleUser           = "user"
+	RoleAdmin          = "admin"
+	RoleCareerServices = "career_services"
+)
+
+// Roles granted for a company or a job
+const (
+	RoleOwner         = "owner"
+	RoleRecruiter     = "recruiter"
+	RoleHiringManager = "hiring_manager"
+	RoleInterviewer   = "interviewer"
+)
+
+// matrix lists the permissions of every role except admin, which has them all
+var matrix = map[string][]Permission{
+	RoleCareerServices: {
+		JobsCreate, JobsImport, JobsRead, ApplicationsRead, ApplicationsExport,
+		AnalyticsRead, CompanyRead, ModerationReview,
+	},
+	RoleOwner: {
+		JobsCreate, JobsRead, JobsUpdate, JobsDelete, JobsTeam,
+		ApplicationsRead, ApplicationsExport, ApplicationsUpdateStatus,
+		AnalyticsRead, CompanyRead, CompanyUpdate, CompanyMembers,
+	},
+	RoleRecruiter: {
+		JobsCreate, JobsRead, JobsUpdate, JobsDelete, JobsTeam,
+		ApplicationsRead, ApplicationsExport, ApplicationsUpdateStatus,
+		AnalyticsRead, CompanyRead, CompanyUpdate,
+	},
+	RoleHiringManager: {
+		JobsRead, JobsUpdate,
+		ApplicationsRead, ApplicationsExport, ApplicationsUpdateStatus,
+		AnalyticsRead, CompanyRead,
+	},
+	RoleInterviewer: {
+		JobsRead, ApplicationsRead, CompanyRead,
+	},
+}
+
+// SiteRoles are the roles an account can have
+var SiteRoles = []string{RoleUser, RoleCareerServices, RoleAdmin}
+
+// CompanyRoles are the roles a company member can have
+var CompanyRoles = []string{RoleOwner, RoleRecruiter, RoleHiringManager, RoleInterviewer}
+
+// JobRoles are the roles a hiring team member can have
+var JobRoles = []string{RoleRecruiter, RoleHiringManager, RoleInterviewer}
+
+// ValidRole reports whether role is one of roles
+func ValidRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleHas reports whether a role includes a permission
+func RoleHas(role string, permission Permission) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range matrix[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HeldAnywhere reports whether the role or any of the grants gives the
+// permission, for any job or company
+func HeldAnywhere(role string, grants []Grant, permission Permission) bool {
+	if RoleHas(role, permission) {
+		return true
+	}
+	for _, grant := range grants {
+		if RoleHas(grant.Role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant is a role held site-wide, or for the company or job it names
+type Grant struct {
+	Role      string `json:"role"`
+	CompanyID string `json:"companyId,omitempty"`
+	JobID     string `json:"jobId,omitempty"`
+}
+
+// Scope is what an action is performed on. A job scope names the job's
+// company and creator too, so company roles apply to the company's jobs and
+// creators can manage their own. The zero Scope is site-wide: only site-wide
+// grants apply to it.
+type Scope struct {
+	CompanyID string
+	JobID     string
+	CreatedBy string
+}
+
+// covers reports whether the grant applies within a scope
+func (g Grant) covers(scope Scope) bool {
+	switch {
+	case g.JobID != "":
+		return g.JobID == scope.JobID
+	case g.CompanyID != "":
+		return g.CompanyID == scope.CompanyID
+	default:
+		return true
+	}
+}
+
+// Allowed reports whether any of the grants gives the permission in scope
+func Allowed(grants []Grant, permission Permission, scope Scope) bool {
+	for _, grant := range grants {
+		if grant.covers(scope) && RoleHas(grant.Role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithCreator adds the recruiter role on the scope's job for the user who
+// created it. Creators of a company's job keep it only while they are still
+// members of the company.
+func WithCreator(grants []Grant, userID string, scope Scope) []Grant {
+	if scope.JobID == "" || userID == "" || scope.CreatedBy != userID {
+		return grants
+	}
+	member := scope.CompanyID == ""
+	for _, grant := range grants {
+		if grant.JobID == "" && grant.CompanyID == scope.CompanyID {
+			member = true
+		}
+	}
+	if !member {
+		return grants
+	}
+	return append(grants, Grant{Role: RoleRecruiter, JobID: scope.JobID})
+}
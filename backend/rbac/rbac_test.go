@@ -0,0 +1,69 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test admins can do anything and plain users nothing special
+func TestRoleHas(t *testing.T) {
+	assert.True(t, RoleHas(RoleAdmin, UsersManage))
+	assert.True(t, RoleHas(RoleCareerServices, ApplicationsExport))
+	assert.False(t, RoleHas(RoleCareerServices, ApplicationsUpdateStatus))
+	assert.False(t, RoleHas(RoleUser, JobsRead))
+	assert.False(t, RoleHas(RoleInterviewer, ApplicationsUpdateStatus))
+	assert.True(t, RoleHas(RoleHiringManager, ApplicationsUpdateStatus))
+	assert.False(t, RoleHas(RoleRecruiter, CompanyMembers))
+	assert.True(t, RoleHas(RoleOwner, CompanyMembers))
+}
+
+// ✅ Test grants only apply to the company or job they name
+func TestAllowedScopes(t *testing.T) {
+	grants := []Grant{
+		{Role: RoleUser},
+		{Role: RoleRecruiter, CompanyID: "acme"},
+		{Role: RoleInterviewer, JobID: "job-2"},
+	}
+
+	// Company roles cover the company's jobs
+	assert.True(t, Allowed(grants, JobsUpdate, Scope{CompanyID: "acme"}))
+	assert.True(t, Allowed(grants, ApplicationsRead, Scope{CompanyID: "acme", JobID: "job-1"}))
+	assert.False(t, Allowed(grants, JobsUpdate, Scope{CompanyID: "globex", JobID: "job-3"}))
+
+	// Hiring team roles cover their job only, whatever its company
+	assert.True(t, Allowed(grants, ApplicationsRead, Scope{CompanyID: "globex", JobID: "job-2"}))
+	assert.False(t, Allowed(grants, ApplicationsUpdateStatus, Scope{CompanyID: "globex", JobID: "job-2"}))
+	assert.False(t, Allowed(grants, ApplicationsRead, Scope{CompanyID: "globex", JobID: "job-3"}))
+
+	// Scoped roles never reach site-wide actions
+	assert.False(t, Allowed(grants, JobsCreate, Scope{}))
+	assert.True(t, Allowed([]Grant{{Role: RoleCareerServices}}, JobsImport, Scope{}))
+	assert.True(t, Allowed([]Grant{{Role: RoleAdmin}}, JobsDelete, Scope{CompanyID: "globex", JobID: "job-3"}))
+}
+
+// ✅ Test role names are validated against their kind
+func TestValidRole(t *testing.T) {
+	assert.True(t, ValidRole(CompanyRoles, RoleHiringManager))
+	assert.False(t, ValidRole(CompanyRoles, RoleAdmin))
+	assert.False(t, ValidRole(JobRoles, RoleOwner))
+	assert.True(t, ValidRole(SiteRoles, RoleCareerServices))
+}
+
+// ✅ Test job creators manage their job while they stay in its company
+func TestWithCreator(t *testing.T) {
+	scope := Scope{CompanyID: "acme", JobID: "job-1", CreatedBy: "user-1"}
+	member := []Grant{{Role: RoleInterviewer, CompanyID: "acme"}}
+
+	assert.True(t, Allowed(WithCreator(member, "user-1", scope), JobsUpdate, scope))
+	assert.False(t, Allowed(WithCreator(member, "user-2", scope), JobsUpdate, scope))
+	assert.False(t, Allowed(WithCreator(nil, "user-1", scope), JobsUpdate, scope))
+
+	// The creator's role does not spread to the company's other jobs
+	other := Scope{CompanyID: "acme", JobID: "job-2", CreatedBy: "user-3"}
+	assert.False(t, Allowed(WithCreator(member, "user-1", scope), JobsUpdate, other))
+
+	// Jobs outside any company stay with their creator
+	solo := Scope{JobID: "job-3", CreatedBy: "user-1"}
+	assert.True(t, Allowed(WithCreator(nil, "user-1", solo), ApplicationsUpdateStatus, solo))
+}
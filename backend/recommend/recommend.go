@@ -0,0 +1,267 @@
+// Package recommend is an in-process, content-based recommender. Documents
+// (jobs) are indexed as term-frequency vectors and scored against a profile
+// with TF-IDF cosine similarity. Document frequencies are kept up to date on
+// every Upsert/Remove, so the index never needs a full rebuild.
+package recommend
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Field weights: a term in a job title says more than one in the description
+const (
+	TitleWeight       = 3.0
+	RequirementWeight = 2.0
+	BodyWeight        = 1.0
+)
+
+var tokenPattern = regexp.MustCompile(`[a-z0-9][a-z0-9+#]*(?:\.[a-z0-9]+)*`)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "has": true, "have": true, "in": true, "is": true,
+	"it": true, "its": true, "of": true, "on": true, "or": true, "our": true, "that": true,
+	"the": true, "their": true, "this": true, "to": true, "we": true, "will": true, "with": true,
+	"you": true, "your": true, "years": true, "year": true, "experience": true, "strong": true,
+	"knowledge": true, "skills": true, "ability": true, "work": true, "team": true, "join": true,
+	"looking": true, "similar": true, "languages": true, "using": true, "etc": true,
+}
+
+// aliases folds common spellings of the same skill onto one term
+var aliases = map[string]string{
+	"golang":     "go",
+	"js":         "javascript",
+	"ts":         "typescript",
+	"reactjs":    "react",
+	"react.js":   "react",
+	"nodejs":     "node.js",
+	"node":       "node.js",
+	"postgres":   "postgresql",
+	"k8s":        "kubernetes",
+	"ml":         "machine-learning",
+	"py":         "python",
+	"restful":    "rest",
+	"apis":       "api",
+	"databases":  "database",
+	"frameworks": "framework",
+}
+
+// Tokenize splits text into normalized terms, dropping stop words
+func Tokenize(text string) []string {
+	var terms []string
+	for _, token := range tokenPattern.FindAllString(strings.ToLower(text), -1) {
+		if alias, ok := aliases[token]; ok {
+			token = alias
+		}
+		if stopWords[token] || (len(token) < 2 && token != "c" && token != "r") {
+			continue
+		}
+		terms = append(terms, token)
+	}
+	return terms
+}
+
+// Vector is a sparse term → weight map
+type Vector map[string]float64
+
+// Add adds every term of text to the vector with the given weight
+func (v Vector) Add(text string, weight float64) {
+	for _, term := range Tokenize(text) {
+		v[term] += weight
+	}
+}
+
+// AddVector adds another vector, scaled by weight
+func (v Vector) AddVector(other Vector, weight float64) {
+	for term, value := range other {
+		v[term] += value * weight
+	}
+}
+
+// Document is something that can be recommended
+type Document struct {
+	ID           string
+	Title        string
+	Body         string
+	Requirements []string
+}
+
+// Vector returns the document's weighted term-frequency vector
+func (d Document) Vector() Vector {
+	v := Vector{}
+	v.Add(d.Title, TitleWeight)
+	v.Add(d.Body, BodyWeight)
+	for _, requirement := range d.Requirements {
+		v.Add(requirement, RequirementWeight)
+	}
+	return v
+}
+
+// Result is one scored recommendation
+type Result struct {
+	ID    string
+	Score float64
+	// Terms are the shared terms that contributed most to the score, best first
+	Terms []string
+}
+
+// Index holds the term vectors of all indexed documents. It is safe for
+// concurrent use.
+type Index struct {
+	mu   sync.RWMutex
+	docs map[string]Vector
+	df   map[string]int
+}
+
+// NewIndex returns an empty index
+func NewIndex() *Index {
+	return &Index{docs: map[string]Vector{}, df: map[string]int{}}
+}
+
+// Len returns the number of indexed documents
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Has reports whether a document is indexed
+func (idx *Index) Has(id string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	_, ok := idx.docs[id]
+	return ok
+}
+
+// Upsert adds a document or replaces its previous version
+func (idx *Index) Upsert(doc Document) {
+	v := doc.Vector()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(doc.ID)
+	idx.docs[doc.ID] = v
+	for term := range v {
+		idx.df[term]++
+	}
+}
+
+// Remove drops a document from the index
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id string) {
+	old, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for term := range old {
+		if idx.df[term]--; idx.df[term] <= 0 {
+			delete(idx.df, term)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// DocumentVector returns a copy of an indexed document's vector, or nil
+func (idx *Index) DocumentVector(id string) Vector {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	doc, ok := idx.docs[id]
+	if !ok {
+		return nil
+	}
+	v := make(Vector, len(doc))
+	for term, weight := range doc {
+		v[term] = weight
+	}
+	return v
+}
+
+// idf is the smoothed inverse document frequency of a term; callers hold the lock
+func (idx *Index) idf(term string) float64 {
+	return math.Log(float64(1+len(idx.docs))/float64(1+idx.df[term])) + 1
+}
+
+// weigh turns raw term frequencies into unit-length TF-IDF weights; callers
+// hold the lock. Term frequencies are dampened with log(1+tf), which stays
+// positive for the fractional frequencies of profiles built from saved jobs.
+func (idx *Index) weigh(v Vector) Vector {
+	weighted := make(Vector, len(v))
+	var norm float64
+	for term, tf := range v {
+		if tf <= 0 {
+			continue
+		}
+		w := math.Log1p(tf) * idx.idf(term)
+		weighted[term] = w
+		norm += w * w
+	}
+	norm = math.Sqrt(norm)
+	for term := range weighted {
+		weighted[term] /= norm
+	}
+	return weighted
+}
+
+// Query scores every indexed document against the profile vector and returns
+// the best n, skipping excluded IDs and documents with no terms in common.
+// When allow is non-nil only documents it accepts are considered.
+func (idx *Index) Query(profile Vector, n int, exclude map[string]bool, allow func(id string) bool) []Result {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(profile) == 0 {
+		return nil
+	}
+	query := idx.weigh(profile)
+
+	var results []Result
+	for id, raw := range idx.docs {
+		if exclude[id] || (allow != nil && !allow(id)) {
+			continue
+		}
+		doc := idx.weigh(raw)
+
+		type contribution struct {
+			term  string
+			value float64
+		}
+		var score float64
+		var shared []contribution
+		for term, qw := range query {
+			if dw, ok := doc[term]; ok {
+				score += qw * dw
+				shared = append(shared, contribution{term, qw * dw})
+			}
+		}
+		if score <= 0 {
+			continue
+		}
+
+		sort.Slice(shared, func(i, j int) bool { return shared[i].value > shared[j].value })
+		result := Result{ID: id, Score: math.Round(score*1000) / 1000}
+		for _, c := range shared {
+			result.Terms = append(result.Terms, c.term)
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if n > 0 && len(results) > n {
+		results = results[:n]
+	}
+	return results
+}
@@ -0,0 +1,71 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ✅ Test tokenizing folds aliases and drops stop words
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"go", "postgresql", "c++", "node.js"}, Tokenize("Golang and Postgres, C++ with Node.js"))
+}
+
+// ✅ Test ranking, exclusions and matched terms
+func TestIndexQuery(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(Document{ID: "backend", Title: "Backend Engineer", Requirements: []string{"Go", "SQL databases"}})
+	idx.Upsert(Document{ID: "frontend", Title: "Frontend Developer", Requirements: []string{"React", "TypeScript"}})
+	idx.Upsert(Document{ID: "nurse", Title: "Registered Nurse", Body: "Patient care"})
+	assert.Equal(t, 3, idx.Len())
+
+	profile := Vector{}
+	profile.Add("Golang SQL", 1)
+
+	results := idx.Query(profile, 10, nil, nil)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "backend", results[0].ID)
+	assert.ElementsMatch(t, []string{"go", "sql"}, results[0].Terms)
+
+	assert.Empty(t, idx.Query(profile, 10, map[string]bool{"backend": true}, nil))
+	assert.Empty(t, idx.Query(profile, 10, nil, func(id string) bool { return id != "backend" }))
+}
+
+// ✅ Test jobs folded into a profile at a fraction of their weight still count in their favour
+func TestIndexQuerySavedJobProfile(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(Document{ID: "saved", Title: "Data Analyst", Body: "Build Tableau dashboards"})
+	idx.Upsert(Document{ID: "similar", Title: "Reporting Specialist", Body: "Build Tableau dashboards"})
+	idx.Upsert(Document{ID: "nurse", Title: "Registered Nurse", Body: "Patient care"})
+
+	profile := Vector{}
+	profile.AddVector(idx.DocumentVector("saved"), 0.3)
+	for _, weight := range weighOnce(idx, profile) {
+		assert.Greater(t, weight, 0.0)
+	}
+
+	results := idx.Query(profile, 10, map[string]bool{"saved": true}, nil)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "similar", results[0].ID)
+	assert.Greater(t, results[0].Score, 0.0)
+}
+
+func weighOnce(idx *Index, v Vector) Vector {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.weigh(v)
+}
+
+// ✅ Test updating and removing documents keeps document frequencies right
+func TestIndexUpsertRemove(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(Document{ID: "1", Title: "Go Developer"})
+	idx.Upsert(Document{ID: "1", Title: "Python Developer"})
+	assert.Equal(t, 1, idx.Len())
+	assert.Equal(t, 0, idx.df["go"])
+	assert.Equal(t, 1, idx.df["python"])
+
+	idx.Remove("1")
+	assert.Equal(t, 0, idx.Len())
+	assert.Empty(t, idx.df)
+}
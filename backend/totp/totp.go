@@ -0,0 +1,103 @@
+// Package totp implements time-based one-time passwords (RFC 6238), the
+// six-digit codes shown by authenticator apps such as Google Authenticator.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Codes have six digits and change every 30 seconds, the defaults every
+// authenticator app supports
+const (
+	Digits = 6
+	Period = 30 * time.Second
+)
+
+// secretSize is the length of generated secrets in bytes, as recommended
+// for HMAC-SHA1
+const secretSize = 20
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret, base32 encoded
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// decodeSecret accepts secrets the way people type them: lower case, with
+// spaces or padding
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return encoding.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// Step returns the time step a moment falls in
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// code computes the HOTP value (RFC 4226) of a time step
+func code(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
+
+// Code returns the code for a secret at the given time
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return code(key, Step(t)), nil
+}
+
+// Validate checks a code against the time step of t and skew steps either
+// side of it, to allow for clock drift. It returns the step that matched, so
+// callers can refuse a code that was used before.
+func Validate(secret, candidate string, t time.Time, skew int) (int64, bool) {
+	key, err := decodeSecret(secret)
+	candidate = strings.ReplaceAll(candidate, " ", "")
+	if err != nil || len(candidate) != Digits {
+		return 0, false
+	}
+	now := Step(t)
+	for offset := -int64(skew); offset <= int64(skew); offset++ {
+		if subtle.ConstantTimeCompare([]byte(code(key, now+offset)), []byte(candidate)) == 1 {
+			return now + offset, true
+		}
+	}
+	return 0, false
+}
+
+// ProvisioningURI returns the otpauth:// URI that authenticator apps import,
+// usually by scanning it as a QR code
+func ProvisioningURI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period/time.Second)))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
@@ -0,0 +1,68 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The SHA-1 secret of the RFC 6238 test vectors, "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// ✅ Test codes match the RFC 6238 test vectors (last six digits)
+func TestCode(t *testing.T) {
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		got, err := Code(rfcSecret, time.Unix(unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "at %d", unix)
+	}
+
+	// Secrets are accepted the way people type them
+	got, err := Code(strings.ToLower(rfcSecret[:16])+" "+rfcSecret[16:], time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", got)
+}
+
+// ✅ Test validation allows clock drift and reports the matching step
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, _ := Code(rfcSecret, now.Add(-Period))
+
+	step, ok := Validate(rfcSecret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+
+	_, ok = Validate(rfcSecret, previous, now, 0)
+	assert.False(t, ok)
+	_, ok = Validate(rfcSecret, "12345", now, 1)
+	assert.False(t, ok)
+	_, ok = Validate("not base32!", "050471", now, 1)
+	assert.False(t, ok)
+}
+
+// ✅ Test generated secrets work and the provisioning URI carries them
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := Code(secret, time.Now())
+	assert.NoError(t, err)
+	_, ok := Validate(secret, code, time.Now(), 1)
+	assert.True(t, ok)
+
+	uri := ProvisioningURI("GatorHire", "albert@ufl.edu", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/GatorHire:albert@ufl.edu?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=GatorHire")
+}